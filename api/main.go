@@ -3,16 +3,19 @@ package main
 import (
 	"fmt"
 	"log"
-	"strconv"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 
 	"steam-backend/config"
 	"steam-backend/controllers"
 	"steam-backend/middleware"
+	"steam-backend/models"
 	"steam-backend/repositories"
 	"steam-backend/services"
+	"steam-backend/storage"
+	"steam-backend/textfilter"
 )
 
 func main() {
@@ -23,38 +26,181 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	r := gin.Default()
+	//不使用gin.Default()的访问日志，避免记录url中的access_token
+	r := gin.New()
+	r.Use(middleware.Logger(), gin.Recovery())
+	//登录防爆破按c.ClientIP()计数，不能让客户端伪造X-Forwarded-For
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Set trusted proxies failed: %v", err)
+	}
+	//multipart超过该大小的部分会写到临时文件，头像本身的大小限制在AvatarService中检查
+	r.MaxMultipartMemory = cfg.AvatarMaxBytes
 
 	userRepo, err_usr := repositories.NewUserRepository(db)
 	if err_usr != nil {
-		log.Fatalf("Create UserRepository failed: %w", err_usr)
+		log.Fatalf("Create UserRepository failed: %v", err_usr)
 		return
 	}
 	appRepo, err_app := repositories.NewAppRepository(db)
 	if err_app != nil {
-		log.Fatalf("Create UserRepository failed: %w", err_app)
+		log.Fatalf("Create AppRepository failed: %v", err_app)
 		return
 	}
 	friendRepo, err_friend := repositories.NewFriendRepository(db)
 	if err_friend != nil {
-		log.Fatalf("Create UserRepository failed: %w", err_friend)
+		log.Fatalf("Create FriendRepository failed: %v", err_friend)
+		return
+	}
+	friendGroupRepo, err_group := repositories.NewFriendGroupRepository(db)
+	if err_group != nil {
+		log.Fatalf("Create FriendGroupRepository failed: %v", err_group)
+		return
+	}
+	communityRepo, err_community := repositories.NewCommunityRepository(db)
+	if err_community != nil {
+		log.Fatalf("Create CommunityRepository failed: %v", err_community)
+		return
+	}
+	messageRepo, err_message := repositories.NewMessageRepository(db)
+	if err_message != nil {
+		log.Fatalf("Create MessageRepository failed: %v", err_message)
+		return
+	}
+	privacyRepo, err_privacy := repositories.NewPrivacyRepository(db)
+	if err_privacy != nil {
+		log.Fatalf("Create PrivacyRepository failed: %v", err_privacy)
+		return
+	}
+	commentRepo, err_comment := repositories.NewProfileCommentRepository(db)
+	if err_comment != nil {
+		log.Fatalf("Create ProfileCommentRepository failed: %v", err_comment)
+		return
+	}
+	reviewRepo, err_review := repositories.NewReviewRepository(db)
+	if err_review != nil {
+		log.Fatalf("Create ReviewRepository failed: %v", err_review)
+		return
+	}
+	curatorRepo, err_curator := repositories.NewCuratorRepository(db)
+	if err_curator != nil {
+		log.Fatalf("Create CuratorRepository failed: %v", err_curator)
+		return
+	}
+	moderationRepo, err_moderation := repositories.NewModerationRepository(db)
+	if err_moderation != nil {
+		log.Fatalf("Create ModerationRepository failed: %v", err_moderation)
+		return
+	}
+	similarityRepo, err_similarity := repositories.NewSimilarityRepository(db)
+	if err_similarity != nil {
+		log.Fatalf("Create SimilarityRepository failed: %v", err_similarity)
+		return
+	}
+	activityRepo, err_activity := repositories.NewActivityRepository(db)
+	if err_activity != nil {
+		log.Fatalf("Create ActivityRepository failed: %v", err_activity)
 		return
 	}
 	wishlistRepo, err_wishlist := repositories.NewWishlistRepository(db)
 	if err_wishlist != nil {
-		log.Fatalf("Create UserRepository failed: %w", err_wishlist)
+		log.Fatalf("Create WishlistRepository failed: %v", err_wishlist)
 		return
 	}
+	blobStore, err_blob := storage.NewLocalBlobStore(cfg.UploadDir, cfg.UploadBaseURL)
+	if err_blob != nil {
+		log.Fatalf("Create BlobStore failed: %v", err_blob)
+		return
+	}
+	oauthRepo, err_oauth := repositories.NewOAuthRepository(db)
+	if err_oauth != nil {
+		log.Fatalf("Create OAuthRepository failed: %v", err_oauth)
+		return
+	}
+	apiKeyRepo, err_apiKey := repositories.NewAPIKeyRepository(db)
+	if err_apiKey != nil {
+		log.Fatalf("Create APIKeyRepository failed: %v", err_apiKey)
+		return
+	}
+	roleRepo, err_role := repositories.NewRoleRepository(db)
+	if err_role != nil {
+		log.Fatalf("Create RoleRepository failed: %v", err_role)
+		return
+	}
+
+	words, err_words := textfilter.LoadWordLists(cfg.TextFilterWordLists...)
+	if err_words != nil {
+		log.Fatalf("Load text filter word lists failed: %v", err_words)
+		return
+	}
+	textFilterService := services.NewTextFilterService(textfilter.New(words, cfg.TextFilterMaxLinks), moderationRepo,
+		*cfg)
+
+	privacyService := services.NewPrivacyService(privacyRepo, friendRepo)
+	activityService := services.NewActivityService(activityRepo, userRepo, appRepo, privacyService)
+	eventHub := services.NewEventHub(cfg.EventBufferSize, cfg.EventHistorySize, cfg.EventReplayWindow)
+	avatarService := services.NewAvatarService(userRepo, activityService, blobStore, *cfg)
+	moderationService := services.NewModerationService(moderationRepo, userRepo, reviewRepo, commentRepo,
+		avatarService, eventHub, *cfg)
+	userService := services.NewUserService(userRepo, activityService, textFilterService, moderationService, *cfg)
+	appService := services.NewAPPService(appRepo, wishlistRepo, eventHub)
+	recommendationService := services.NewRecommendationService(appRepo, wishlistRepo, similarityRepo, *cfg)
+	presenceService := services.NewPresenceService(storage.NewMemoryPresenceStore(cfg.PresenceTTL), appRepo,
+		friendRepo, eventHub)
+	friendService := services.NewFriendService(friendRepo, friendGroupRepo, userRepo, presenceService, privacyService,
+		eventHub, textFilterService, *cfg)
+	profileService := services.NewProfileService(userRepo, friendRepo, wishlistRepo, commentRepo, privacyService,
+		textFilterService, *cfg)
+	reviewService := services.NewReviewService(reviewRepo, appRepo, userRepo, textFilterService)
+	curatorService := services.NewCuratorService(curatorRepo, appRepo, textFilterService)
+	wishlistService := services.NewWishlistService(wishlistRepo, appRepo, activityService, privacyService)
+	messageService := services.NewMessageService(messageRepo, friendRepo, userRepo, eventHub, textFilterService, *cfg)
+	roleService := services.NewRoleService(roleRepo)
+	communityService := services.NewCommunityService(communityRepo, friendRepo, userRepo, avatarService,
+		textFilterService)
+	accountService := services.NewAccountService(userRepo, friendRepo, wishlistService, avatarService, *cfg)
 
-	userService := services.NewUserService(userRepo, *cfg)
-	appService := services.NewAPPService(appRepo)
-	friendService := services.NewFriendService(friendRepo)
-	wishlistService := services.NewWishlistService(wishlistRepo, appRepo)
+	oauthService := services.NewOAuthService(oauthRepo, *cfg)
+	sessionService := services.NewSessionService(moderationService, userRepo, oauthRepo)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, *cfg)
 
-	userController := controllers.NewUserController(userService)
-	appController := controllers.NewAppController(appService)
-	friendController := controllers.NewFriendController(friendService)
+	services.RunPeriodically("purge deleted accounts", cfg.AccountPurgeInterval, accountService.PurgeDeletedAccounts)
+	services.RunPeriodically("purge oauth codes", cfg.OAuthCodeTTL, oauthService.PurgeExpiredCodes)
+	services.RunPeriodically("flush api key usage", cfg.APIKeyUsageFlush, apiKeyService.FlushUsage)
+	services.RunPeriodically("expire invitations", cfg.InvitationExpiryInterval, friendService.ExpireInvitations)
+	services.RunPeriodically("sweep presences", cfg.PresenceSweepInterval, presenceService.SweepExpired)
+	services.RunPeriodically("purge event history", cfg.EventReplayWindow, eventHub.PurgeHistory)
+	services.RunPeriodically("purge ban cache", cfg.BanCacheTTL, moderationService.PurgeBanCache)
+	services.RunPeriodically("rebuild app similarities", cfg.SimilarityRebuildInterval,
+		recommendationService.RebuildSimilarities)
+	//RunPeriodically第一次执行要等一个间隔，启动时先重建一次，避免重启后长时间没有数据
+	if cfg.SimilarityRebuildInterval > 0 {
+		go func() {
+			if err := recommendationService.RebuildSimilarities(); err != nil {
+				log.Printf("Rebuild app similarities failed: %v", err)
+			}
+		}()
+	}
+
+	if err := roleService.BootstrapAdmins(cfg.AdminUserIDs); err != nil {
+		log.Printf("Bootstrap admins failed: %v", err)
+	}
+
+	userController := controllers.NewUserController(userService, avatarService, privacyService)
+	appController := controllers.NewAppController(appService, recommendationService)
+	profileController := controllers.NewProfileController(profileService)
+	reviewController := controllers.NewReviewController(reviewService)
+	curatorController := controllers.NewCuratorController(curatorService)
+	moderationController := controllers.NewModerationController(moderationService)
+	friendController := controllers.NewFriendController(friendService, activityService)
 	wishlistController := controllers.NewWishlistController(wishlistService)
+	adminController := controllers.NewAdminController(roleService)
+	accountController := controllers.NewAccountController(accountService)
+	oauthController := controllers.NewOAuthController(oauthService)
+	apiKeyController := controllers.NewAPIKeyController(apiKeyService)
+	presenceController := controllers.NewPresenceController(presenceService)
+	eventController := controllers.NewEventController(eventHub, cfg.EventPingInterval)
+	messageController := controllers.NewMessageController(messageService)
+	communityController := controllers.NewCommunityController(communityService)
 
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -62,6 +208,11 @@ func main() {
 			"message": "backend service is running"})
 	})
 
+	r.Static(cfg.UploadBaseURL, cfg.UploadDir)
+
+	auth := middleware.AuthMiddleware(cfg, sessionService)
+	optionalAuth := middleware.OptionalAuthMiddleware(cfg, sessionService)
+
 	api := r.Group("/api")
 	{
 		userRoutes := api.Group("/user")
@@ -69,41 +220,217 @@ func main() {
 			userRoutes.POST("/join", userController.Register)
 			userRoutes.POST("/login", userController.Login)
 			userRoutes.GET("/available", userController.CheckUsernameAvailable)
-			userRoutes.GET("/search", userController.SearchUsers)
-			userRoutes.GET("/:id", userController.GetUserByID)
+			userRoutes.GET("/search", optionalAuth, userController.SearchUsers)
+			userRoutes.GET("/:id", optionalAuth, profileController.GetProfile)
+			userRoutes.GET("/:id/comments", optionalAuth, profileController.GetComments)
+			userRoutes.POST("/:id/comments", auth, profileController.PostComment)
+			userRoutes.DELETE("/:id/comments/:commentId", auth, profileController.DeleteComment)
+			userRoutes.GET("/:id/friends", optionalAuth, friendController.GetUserFriendList)
+			userRoutes.GET("/:id/wishlist", optionalAuth, wishlistController.GetUserWishlist)
 
+			//第三方应用可以通过profile权限访问
+			profileRoutes := userRoutes.Group("/")
+			profileRoutes.Use(middleware.AuthMiddleware(cfg, sessionService, "profile"))
+			{
+				profileRoutes.GET("/info", userController.GetUserInfo)
+				profileRoutes.PATCH("/info", userController.UpdateProfile)
+				profileRoutes.POST("/avatar", userController.UploadAvatar)
+			}
+
+			//账号安全相关操作只允许用户本人
 			authUserRoutes := userRoutes.Group("/")
-			authUserRoutes.Use(middleware.AuthMiddleware(cfg))
+			authUserRoutes.Use(auth)
+			{
+				authUserRoutes.POST("/username", userController.ChangeUsername)
+				authUserRoutes.GET("/username/history", userController.GetUsernameHistory)
+				authUserRoutes.POST("/password", userController.ChangePassword)
+				authUserRoutes.GET("/export", accountController.ExportData)
+				authUserRoutes.POST("/delete", accountController.ScheduleDeletion)
+				authUserRoutes.POST("/delete/cancel", accountController.CancelDeletion)
+				authUserRoutes.GET("/privacy", userController.GetPrivacySettings)
+				authUserRoutes.PATCH("/privacy", userController.UpdatePrivacySettings)
+			}
+
+		}
+
+		oauthRoutes := api.Group("/oauth")
+		{
+			oauthRoutes.POST("/token", oauthController.Token)
+
+			authOAuthRoutes := oauthRoutes.Group("/")
+			authOAuthRoutes.Use(auth)
+			{
+				authOAuthRoutes.POST("/clients", oauthController.RegisterClient)
+				authOAuthRoutes.GET("/clients", oauthController.GetClients)
+				authOAuthRoutes.DELETE("/clients/:clientId", oauthController.DeleteClient)
+				authOAuthRoutes.GET("/authorize", oauthController.GetConsent)
+				authOAuthRoutes.POST("/authorize", oauthController.Consent)
+			}
+		}
+
+		presenceRoutes := api.Group("/presence")
+		presenceRoutes.Use(auth)
+		{
+			presenceRoutes.POST("/heartbeat", presenceController.Heartbeat)
+			presenceRoutes.POST("/offline", presenceController.GoOffline)
+		}
+
+		eventRoutes := api.Group("/events")
+		eventRoutes.Use(middleware.QueryTokenMiddleware(), auth)
+		{
+			eventRoutes.GET("/ws", eventController.WebSocket)
+			eventRoutes.GET("/stream", eventController.Stream)
+		}
+
+		messageRoutes := api.Group("/message")
+		messageRoutes.Use(auth)
+		{
+			messageRoutes.POST("/send", messageController.SendMessage)
+			messageRoutes.GET("/conversations", messageController.GetConversations)
+			messageRoutes.GET("/unread", messageController.GetUnreadCount)
+			messageRoutes.GET("/with/:userId", messageController.GetConversation)
+			messageRoutes.POST("/read/:userId", messageController.MarkRead)
+		}
+
+		communityRoutes := api.Group("/community")
+		{
+			communityRoutes.POST("", auth, communityController.CreateGroup)
+			communityRoutes.GET("/:id", optionalAuth, communityController.GetGroup)
+			communityRoutes.GET("/:id/members", communityController.GetMembers)
+			communityRoutes.GET("/user/:userId", optionalAuth, communityController.GetUserGroups)
+
+			authCommunityRoutes := communityRoutes.Group("/")
+			authCommunityRoutes.Use(auth)
+			{
+				authCommunityRoutes.GET("/mine", communityController.GetUserGroups)
+				authCommunityRoutes.PATCH("/:id", communityController.UpdateGroup)
+				authCommunityRoutes.POST("/:id/avatar", communityController.UploadAvatar)
+				authCommunityRoutes.DELETE("/:id", communityController.DeleteGroup)
+				authCommunityRoutes.POST("/:id/join", communityController.JoinGroup)
+				authCommunityRoutes.POST("/:id/leave", communityController.LeaveGroup)
+				authCommunityRoutes.POST("/:id/invite", communityController.InviteUser)
+				authCommunityRoutes.PUT("/:id/member/:userId/role", communityController.SetMemberRole)
+				authCommunityRoutes.DELETE("/:id/member/:userId", communityController.KickMember)
+				authCommunityRoutes.POST("/invite/accept/:id", communityController.AcceptInvitation)
+				authCommunityRoutes.POST("/invite/refuse/:id", communityController.RefuseInvitation)
+				authCommunityRoutes.GET("/invite/list/received", communityController.GetReceivedInvitations)
+			}
+		}
+
+		curatorRoutes := api.Group("/curator")
+		{
+			curatorRoutes.POST("", auth, curatorController.CreateCurator)
+			curatorRoutes.GET("/:id", optionalAuth, curatorController.GetCurator)
+			curatorRoutes.GET("/:id/lists", curatorController.GetLists)
+			curatorRoutes.GET("/:id/list/:listId", curatorController.GetList)
+
+			authCuratorRoutes := curatorRoutes.Group("/")
+			authCuratorRoutes.Use(auth)
 			{
-				authUserRoutes.GET("/info", userController.GetUserInfo)
+				authCuratorRoutes.GET("/following", curatorController.GetFollowedCurators)
+				authCuratorRoutes.PATCH("/:id", curatorController.UpdateCurator)
+				authCuratorRoutes.POST("/:id/follow", curatorController.Follow)
+				authCuratorRoutes.DELETE("/:id/follow", curatorController.Follow)
+				authCuratorRoutes.POST("/:id/list", curatorController.CreateList)
+				authCuratorRoutes.PUT("/:id/list/:listId", curatorController.UpdateList)
+				authCuratorRoutes.DELETE("/:id/list/:listId", curatorController.DeleteList)
+				authCuratorRoutes.PUT("/:id/list/:listId/items", curatorController.SetListItems)
 			}
+		}
 
+		developerRoutes := api.Group("/developer")
+		developerRoutes.Use(auth, middleware.RequireRole(roleService, models.RoleDeveloper, models.RoleAdmin))
+		{
+			developerRoutes.POST("/keys", apiKeyController.CreateKey)
+			developerRoutes.GET("/keys", apiKeyController.GetKeys)
+			developerRoutes.DELETE("/keys/:id", apiKeyController.RevokeKey)
+		}
+
+		//开发者后端通过X-API-Key调用，复用公开接口的处理函数
+		serverRoutes := api.Group("/server")
+		{
+			catalogRoutes := serverRoutes.Group("/app")
+			catalogRoutes.Use(middleware.APIKeyMiddleware(apiKeyService, moderationService, models.APIScopeCatalogRead))
+			{
+				catalogRoutes.GET("/search/suggestions", appController.GetSearchSuggestions)
+				catalogRoutes.GET("/:id", appController.GetAppByID)
+			}
+
+			serverUserRoutes := serverRoutes.Group("/user")
+			serverUserRoutes.Use(middleware.APIKeyMiddleware(apiKeyService, moderationService, models.APIScopeUsersRead))
+			{
+				serverUserRoutes.GET("/search", userController.SearchUsers)
+				serverUserRoutes.GET("/:id", profileController.GetProfile)
+			}
+		}
+
+		adminRoutes := api.Group("/admin")
+		adminRoutes.Use(auth, middleware.RequireRole(roleService, models.RoleAdmin))
+		{
+			adminRoutes.POST("/user/unlock", userController.UnlockAccount)
+			adminRoutes.POST("/role/grant", adminController.GrantRole)
+			adminRoutes.POST("/role/revoke", adminController.RevokeRole)
+			adminRoutes.GET("/role/logs", adminController.GetRoleAuditLogs)
+			adminRoutes.PUT("/app/:id/discount", appController.UpdateDiscount)
+			adminRoutes.PUT("/api-keys/:id/rate-limit", apiKeyController.SetRateLimit)
+		}
+
+		api.POST("/report", auth, moderationController.Report)
+
+		moderationRoutes := api.Group("/moderation")
+		moderationRoutes.Use(auth, middleware.RequireRole(roleService, models.RoleModerator, models.RoleAdmin))
+		{
+			moderationRoutes.GET("/reports", moderationController.GetReports)
+			moderationRoutes.POST("/reports/:id/resolve", moderationController.ResolveReport)
+			moderationRoutes.DELETE("/bans/:userId", moderationController.LiftBan)
 		}
 
 		appRoutes := api.Group("/app")
 		{
-			appRoutes.GET("/recommendations", appController.GetRecommendations)
+			appRoutes.GET("/recommendations", optionalAuth, appController.GetRecommendations)
 			appRoutes.GET("/specials", appController.GetSpecials)
 			appRoutes.GET("/search/suggestions", appController.GetSearchSuggestions)
+			appRoutes.GET("/curated", auth, curatorController.GetFollowedPicks)
 			appRoutes.GET("/:id", appController.GetAppByID)
+			appRoutes.GET("/:id/also-wishlisted", appController.GetAlsoWishlisted)
+			appRoutes.GET("/:id/reviews", reviewController.GetReviews)
+			appRoutes.POST("/:id/review", auth, reviewController.CreateReview)
+			appRoutes.PUT("/:id/review", auth, reviewController.UpdateReview)
+			appRoutes.DELETE("/:id/review", auth, reviewController.DeleteReview)
+			appRoutes.PUT("/review/:reviewId/helpful", auth, reviewController.MarkHelpful)
+			appRoutes.DELETE("/review/:reviewId/helpful", auth, reviewController.MarkHelpful)
 		}
 
 		friendRoutes := api.Group("/friend")
-		friendRoutes.Use(middleware.AuthMiddleware(cfg))
+		friendRoutes.Use(middleware.AuthMiddleware(cfg, sessionService, "friends"))
 		{
 			friendRoutes.GET("/num", friendController.GetFriendCount)
 			friendRoutes.GET("/list", friendController.GetFriendList)
 			friendRoutes.POST("/invite", friendController.SendInvitation)
 			friendRoutes.POST("/invite/accept/:id", friendController.AcceptInvitation)
 			friendRoutes.POST("/invite/refuse/:id", friendController.RefuseInvitation)
+			friendRoutes.DELETE("/invite/:id", friendController.CancelInvitation)
 			friendRoutes.GET("/invite/list/received", friendController.GetReceivedInvitations)
 			friendRoutes.GET("/invite/list/sent", friendController.GetSentInvitations)
 			friendRoutes.POST("/delete", friendController.RemoveFriend)
 			friendRoutes.GET("/check", friendController.CheckFriendship)
+			friendRoutes.GET("/mutual/:userId", friendController.GetMutualFriends)
+			friendRoutes.GET("/suggestions", friendController.GetSuggestions)
+			friendRoutes.GET("/feed", friendController.GetFeed)
+			friendRoutes.POST("/block", friendController.BlockUser)
+			friendRoutes.DELETE("/block/:userId", friendController.UnblockUser)
+			friendRoutes.GET("/block/list", friendController.GetBlockedList)
+			friendRoutes.POST("/group", friendController.CreateGroup)
+			friendRoutes.GET("/group/list", friendController.GetGroups)
+			friendRoutes.PUT("/group/:id", friendController.RenameGroup)
+			friendRoutes.DELETE("/group/:id", friendController.DeleteGroup)
+			friendRoutes.POST("/group/:id/member", friendController.AddGroupMember)
+			friendRoutes.DELETE("/group/:id/member/:friendId", friendController.RemoveGroupMember)
+			friendRoutes.PUT("/nickname", friendController.SetNickname)
 		}
 
 		wishlistRoutes := api.Group("/wishlist")
-		wishlistRoutes.Use(middleware.AuthMiddleware(cfg))
+		wishlistRoutes.Use(middleware.AuthMiddleware(cfg, sessionService, "wishlist"))
 		{
 			wishlistRoutes.GET("/size", wishlistController.GetWishlistSize)
 			wishlistRoutes.GET("", wishlistController.GetWishlist)
@@ -114,7 +441,10 @@ func main() {
 		}
 	}
 
-	serverPort_int := strconv.Atoi(cfg.ServerPort)
+	serverPort_int, err := strconv.Atoi(cfg.ServerPort)
+	if err != nil {
+		log.Fatalf("Invalid server port %q: %v", cfg.ServerPort, err)
+	}
 	serverAddr := fmt.Sprintf(":%d", serverPort_int)
 	log.Printf("Server starting on %s", serverAddr)
 	if err := r.Run(serverAddr); err != nil {
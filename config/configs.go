@@ -3,6 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,6 +18,70 @@ type Config struct {
 	DBPassword string
 	DBName     string
 	JWTSecret  string
+
+	//登录防爆破：连续失败达到上限后锁定，未达上限时按指数退避
+	LoginMaxFailures      int
+	LoginMaxFailuresPerIP int
+	LoginBackoffBase      time.Duration
+	LoginLockoutDuration  time.Duration
+	//反向代理的IP或CIDR，只有来自这些地址的X-Forwarded-For才会被用来取客户端IP，为空表示不信任任何代理
+	TrustedProxies []string
+
+	AdminUserIDs []uint64
+
+	UsernameChangeCooldown time.Duration
+
+	//上传文件保存目录及对外访问前缀
+	UploadDir      string
+	UploadBaseURL  string
+	AvatarMaxBytes int64
+
+	AccountDeletionGracePeriod time.Duration
+	AccountPurgeInterval       time.Duration
+
+	OAuthCodeTTL  time.Duration
+	OAuthTokenTTL time.Duration
+
+	//API key每分钟请求数
+	APIKeyDefaultRateLimit int
+	APIKeyMaxRateLimit     int
+	APIKeyUsageFlush       time.Duration
+
+	InvitationExpiry         time.Duration
+	InvitationExpiryInterval time.Duration
+
+	//超过PresenceTTL没有心跳即视为离线
+	PresenceTTL           time.Duration
+	PresenceSweepInterval time.Duration
+
+	//实时推送：每个连接的缓冲事件数，以及断线重连时可补发的事件数和时间窗口
+	EventBufferSize   int
+	EventHistorySize  int
+	EventReplayWindow time.Duration
+	EventPingInterval time.Duration
+
+	//私信长度按字符计算，发送频率按每分钟条数限制
+	MessageMaxLength int
+	MessageRateLimit int
+
+	//每个用户每分钟最多发表的主页留言数
+	ProfileCommentRateLimit int
+
+	//封禁状态在每个实例内的缓存时间，多实例部署时封禁和解封最多延迟这么久才在其他实例生效
+	BanCacheTTL time.Duration
+
+	//文本过滤：词表文件路径，默认处理方式及按字段覆盖的处理方式(reject,mask,flag)
+	TextFilterWordLists   []string
+	TextFilterDefaultMode string
+	TextFilterModes       map[string]string
+	TextFilterMaxLinks    int
+
+	//"加入愿望单的用户还加入了"：重建间隔，共同加入的最少用户数，每个游戏保留的相关游戏数
+	//SimilarityMinSupport是唯一防止通过相关游戏反推出某个用户私密愿望单的限制，
+	//低于similarityMinSupportFloor时按下限处理
+	SimilarityRebuildInterval time.Duration
+	SimilarityMinSupport      int
+	SimilarityMaxRelated      int
 }
 
 func LoadConfig() *Config {
@@ -31,6 +98,57 @@ func LoadConfig() *Config {
 		DBPassword: getenv("DB_PASSWORD", "123456"),
 		DBName:     getenv("DB_NAME", "steam"),
 		JWTSecret:  getenv("JWT_SECRET", "key"),
+
+		LoginMaxFailures:      getenvInt("LOGIN_MAX_FAILURES", 5),
+		LoginMaxFailuresPerIP: getenvInt("LOGIN_MAX_FAILURES_PER_IP", 20),
+		LoginBackoffBase:      getenvDuration("LOGIN_BACKOFF_BASE", time.Second),
+		LoginLockoutDuration:  getenvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		TrustedProxies:        getenvList("TRUSTED_PROXIES"),
+
+		AdminUserIDs: getenvUint64List("ADMIN_USER_IDS"),
+
+		UsernameChangeCooldown: getenvDuration("USERNAME_CHANGE_COOLDOWN", 30*24*time.Hour),
+
+		UploadDir:      getenv("UPLOAD_DIR", "./uploads"),
+		UploadBaseURL:  getenv("UPLOAD_BASE_URL", "/uploads"),
+		AvatarMaxBytes: int64(getenvInt("AVATAR_MAX_BYTES", 2<<20)),
+
+		AccountDeletionGracePeriod: getenvDuration("ACCOUNT_DELETION_GRACE_PERIOD", 14*24*time.Hour),
+		AccountPurgeInterval:       getenvDuration("ACCOUNT_PURGE_INTERVAL", time.Hour),
+
+		OAuthCodeTTL:  getenvDuration("OAUTH_CODE_TTL", 10*time.Minute),
+		OAuthTokenTTL: getenvDuration("OAUTH_TOKEN_TTL", time.Hour),
+
+		APIKeyDefaultRateLimit: getenvInt("API_KEY_DEFAULT_RATE_LIMIT", 600),
+		APIKeyMaxRateLimit:     getenvInt("API_KEY_MAX_RATE_LIMIT", 6000),
+		APIKeyUsageFlush:       getenvDuration("API_KEY_USAGE_FLUSH", time.Minute),
+
+		InvitationExpiry:         getenvDuration("INVITATION_EXPIRY", 30*24*time.Hour),
+		InvitationExpiryInterval: getenvDuration("INVITATION_EXPIRY_INTERVAL", time.Hour),
+
+		PresenceTTL:           getenvDuration("PRESENCE_TTL", 90*time.Second),
+		PresenceSweepInterval: getenvDuration("PRESENCE_SWEEP_INTERVAL", time.Minute),
+
+		EventBufferSize:   getenvInt("EVENT_BUFFER_SIZE", 64),
+		EventHistorySize:  getenvInt("EVENT_HISTORY_SIZE", 100),
+		EventReplayWindow: getenvDuration("EVENT_REPLAY_WINDOW", 10*time.Minute),
+		EventPingInterval: getenvDuration("EVENT_PING_INTERVAL", 30*time.Second),
+
+		MessageMaxLength: getenvInt("MESSAGE_MAX_LENGTH", 2000),
+		MessageRateLimit: getenvInt("MESSAGE_RATE_LIMIT", 30),
+
+		ProfileCommentRateLimit: getenvInt("PROFILE_COMMENT_RATE_LIMIT", 5),
+
+		BanCacheTTL: getenvDuration("BAN_CACHE_TTL", 30*time.Second),
+
+		TextFilterWordLists:   getenvList("TEXT_FILTER_WORDLISTS"),
+		TextFilterDefaultMode: getenv("TEXT_FILTER_DEFAULT_MODE", "reject"),
+		TextFilterModes:       getenvMap("TEXT_FILTER_MODES"),
+		TextFilterMaxLinks:    getenvInt("TEXT_FILTER_MAX_LINKS", 2),
+
+		SimilarityRebuildInterval: getenvDuration("SIMILARITY_REBUILD_INTERVAL", 6*time.Hour),
+		SimilarityMinSupport:      getenvInt("SIMILARITY_MIN_SUPPORT", 3),
+		SimilarityMaxRelated:      getenvInt("SIMILARITY_MAX_RELATED", 20),
 	}
 }
 
@@ -40,3 +158,73 @@ func getenv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getenvInt(key string, defaultValue int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	res, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("invalid %s=%q, using default %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return res
+}
+
+// getenvDuration 解析time.ParseDuration格式的值，如"30s"、"15m"
+func getenvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	res, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("invalid %s=%q, using default %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return res
+}
+
+// getenvUint64List 解析逗号分隔的id列表，如"1,2,3"
+func getenvUint64List(key string) []uint64 {
+	var res []uint64
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			log.Printf("invalid id %q in %s, skipped", part, key)
+			continue
+		}
+		res = append(res, id)
+	}
+	return res
+}
+
+// getenvList 解析逗号分隔的字符串列表，忽略空项
+func getenvList(key string) []string {
+	var res []string
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			res = append(res, part)
+		}
+	}
+	return res
+}
+
+// getenvMap 解析逗号分隔的key=value列表，如"bio=mask,review=flag"
+func getenvMap(key string) map[string]string {
+	res := make(map[string]string)
+	for _, part := range getenvList(key) {
+		k, v, ok := strings.Cut(part, "=")
+		if !ok {
+			log.Printf("invalid entry %q in %s, skipped", part, key)
+			continue
+		}
+		res[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return res
+}
@@ -39,9 +39,35 @@ func InitDb(cfg *Config) (*gorm.DB, error) {
 func autoMigrate(db *gorm.DB) error {
 	return db.AutoMigrate(
 		&models.User{},
+		&models.UsernameHistory{},
 		&models.App{},
 		&models.Friend{},
 		&models.Invitation{},
+		&models.Block{},
+		&models.FriendGroup{},
+		&models.FriendGroupMember{},
+		&models.FriendNickname{},
+		&models.Message{},
+		&models.CommunityGroup{},
+		&models.CommunityMember{},
+		&models.CommunityInvitation{},
+		&models.Activity{},
+		&models.PrivacySettings{},
+		&models.ProfileComment{},
+		&models.Review{},
+		&models.ReviewHelpfulVote{},
+		&models.Curator{},
+		&models.CuratorList{},
+		&models.CuratorListItem{},
+		&models.CuratorFollower{},
+		&models.Report{},
+		&models.UserBan{},
+		&models.UserWarning{},
+		&models.AppSimilarity{},
 		&models.WishlistItem{},
+		&models.RoleAuditLog{},
+		&models.OAuthClient{},
+		&models.OAuthAuthorizationCode{},
+		&models.APIKey{},
 	)
 }
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"steam-backend/models"
+	"steam-backend/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AccountController struct {
+	accountService services.AccountService
+}
+
+func NewAccountController(accountService services.AccountService) *AccountController {
+	return &AccountController{accountService: accountService}
+}
+
+// ExportData format=json(默认)直接下载json文件，format=zip按数据类别拆成多个文件打包
+func (ctrl *AccountController) ExportData(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "zip" {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "format must be json or zip"))
+		return
+	}
+
+	export, err := ctrl.accountService.ExportData(userID.(uint64))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "user not exists"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "export failed"))
+		return
+	}
+
+	fileName := fmt.Sprintf("steam-export-%d-%s", export.Profile.UserID, export.ExportedAt.Format("20060102"))
+	if format == "json" {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, fileName))
+		c.IndentedJSON(http.StatusOK, export)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, fileName))
+	c.Header("Content-Type", "application/zip")
+	c.Status(http.StatusOK)
+	if err := writeExportZip(c.Writer, export); err != nil {
+		//响应头已经发出，只能中断连接让客户端感知下载失败
+		c.Error(err)
+		c.Abort()
+	}
+}
+
+func writeExportZip(w http.ResponseWriter, export *models.AccountExportDto) error {
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"username_history.json", export.UsernameHistory},
+		{"friends.json", export.Friends},
+		{"invitations_sent.json", export.SentInvitations},
+		{"invitations_received.json", export.ReceivedInvitations},
+		{"wishlist.json", export.Wishlist},
+		{"blocked_users.json", export.BlockedUsers},
+		{"privacy_settings.json", export.PrivacySettings},
+		{"messages.json", export.Messages},
+		{"profile_comments.json", export.ProfileComments},
+		{"reviews.json", export.Reviews},
+		{"helpful_votes.json", export.HelpfulVotes},
+		{"friend_groups.json", export.FriendGroups},
+		{"friend_group_members.json", export.FriendGroupMembers},
+		{"friend_nicknames.json", export.FriendNicknames},
+		{"communities.json", export.Communities},
+		{"community_memberships.json", export.CommunityMemberships},
+		{"community_invitations.json", export.CommunityInvitations},
+		{"activities.json", export.Activities},
+		{"curators.json", export.Curators},
+		{"curator_lists.json", export.CuratorLists},
+		{"curator_list_items.json", export.CuratorListItems},
+		{"followed_curators.json", export.FollowedCurators},
+		{"bans.json", export.Bans},
+		{"warnings.json", export.Warnings},
+		{"oauth_clients.json", export.OAuthClients},
+		{"api_keys.json", export.APIKeys},
+	}
+
+	zw := zip.NewWriter(w)
+	for _, file := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(file.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func (ctrl *AccountController) ScheduleDeletion(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	var req models.DeleteAccountRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "param error"))
+		return
+	}
+
+	at, err := ctrl.accountService.ScheduleDeletion(userID.(uint64), req.Password)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(gin.H{"deletionScheduledAt": at.Format(time.RFC3339)},
+		"account deletion scheduled"))
+}
+
+func (ctrl *AccountController) CancelDeletion(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	if err := ctrl.accountService.CancelDeletion(userID.(uint64)); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "account deletion canceled"))
+}
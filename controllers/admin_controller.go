@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"steam-backend/models"
+	"steam-backend/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AdminController struct {
+	roleService services.RoleService
+}
+
+func NewAdminController(roleService services.RoleService) *AdminController {
+	return &AdminController{roleService: roleService}
+}
+
+func (ctrl *AdminController) GrantRole(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	var req models.RoleRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "param error"))
+		return
+	}
+
+	err := ctrl.roleService.GrantRole(userID.(uint64), req.UserID, req.Role)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "user not exists"))
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "grant successful"))
+}
+
+func (ctrl *AdminController) RevokeRole(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	var req models.RoleRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "param error"))
+		return
+	}
+
+	err := ctrl.roleService.RevokeRole(userID.(uint64), req.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "user not exists"))
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "revoke successful"))
+}
+
+func (ctrl *AdminController) GetRoleAuditLogs(c *gin.Context) {
+	page, pageSize := parsePage(c)
+
+	var targetID uint64
+	if idStr := c.Query("userId"); idStr != "" {
+		id, err := strconv.ParseUint(idStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild userId"))
+			return
+		}
+		targetID = id
+	}
+
+	logs, err := ctrl.roleService.GetAuditLogs(targetID, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get audit logs failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(logs))
+}
@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"steam-backend/models"
+	"steam-backend/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type APIKeyController struct {
+	apiKeyService services.APIKeyService
+}
+
+func NewAPIKeyController(apiKeyService services.APIKeyService) *APIKeyController {
+	return &APIKeyController{apiKeyService: apiKeyService}
+}
+
+func (ctrl *APIKeyController) CreateKey(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	var req models.APIKeyRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "param error"))
+		return
+	}
+
+	key, err := ctrl.apiKeyService.CreateKey(userID.(uint64), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(key))
+}
+
+func (ctrl *APIKeyController) GetKeys(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	keys, err := ctrl.apiKeyService.GetKeys(userID.(uint64))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get api keys failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(keys))
+}
+
+func (ctrl *APIKeyController) RevokeKey(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	err = ctrl.apiKeyService.RevokeKey(id, userID.(uint64))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "api key not exists"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "revoke api key failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "revoke successful"))
+}
+
+// SetRateLimit 管理员接口，开发者不能自行提高限额
+func (ctrl *APIKeyController) SetRateLimit(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	var req models.APIKeyRateLimitRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "param error"))
+		return
+	}
+
+	err = ctrl.apiKeyService.SetRateLimit(id, req.RateLimit)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "api key not exists"))
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "rate limit updated"))
+}
@@ -12,12 +12,15 @@ import (
 )
 
 type AppController struct {
-	appService services.AppService
+	appService            services.AppService
+	recommendationService services.RecommendationService
 }
 
-func NewAppController(appService services.AppService) *AppController {
+func NewAppController(appService services.AppService,
+	recommendationService services.RecommendationService) *AppController {
 	return &AppController{
-		appService: appService,
+		appService:            appService,
+		recommendationService: recommendationService,
 	}
 }
 
@@ -32,7 +35,13 @@ func (ctrl *AppController) GetRecommendations(c *gin.Context) {
 		limit = 30
 	}
 
-	recommendations, err := ctrl.appService.GetRecommendations(limit)
+	//登录用户按愿望单推荐，未登录时返回全站推荐
+	var userID uint64
+	if id, exists := c.Get("userId"); exists {
+		userID = id.(uint64)
+	}
+
+	recommendations, err := ctrl.recommendationService.GetRecommendations(userID, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get recommendations failed"))
 		return
@@ -105,3 +114,55 @@ func (ctrl *AppController) GetAppByID(c *gin.Context) {
 
 	c.JSON(http.StatusOK, models.SuccessResponse(app))
 }
+
+// GetAlsoWishlisted 把该游戏加入愿望单的用户还加入了哪些游戏
+func (ctrl *AppController) GetAlsoWishlisted(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	//上限由服务按相似度表保留的数量控制
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+
+	apps, err := ctrl.recommendationService.GetAlsoWishlisted(id, limit)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "id not exists"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get also wishlisted failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(apps))
+}
+
+func (ctrl *AppController) UpdateDiscount(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	var req models.DiscountRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "request param error"))
+		return
+	}
+
+	if err := ctrl.appService.UpdateDiscount(id, req.Discount); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "app not exists"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "update discount failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "update successful"))
+}
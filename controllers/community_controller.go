@@ -0,0 +1,399 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"steam-backend/models"
+	"steam-backend/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CommunityController struct {
+	communityService services.CommunityService
+}
+
+func NewCommunityController(communityService services.CommunityService) *CommunityController {
+	return &CommunityController{communityService: communityService}
+}
+
+// respondCommunityError 群组或成员不存在返回404，权限不足返回403，其余视为请求错误
+func (ctrl *CommunityController) respondCommunityError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "not exists"))
+	case errors.Is(err, services.ErrCommunityForbidden):
+		c.JSON(http.StatusForbidden, models.ForbiddenResponse(nil, err.Error()))
+	default:
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+	}
+}
+
+func (ctrl *CommunityController) CreateGroup(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	var req models.CreateCommunityRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "request param error"))
+		return
+	}
+
+	group, err := ctrl.communityService.CreateGroup(userID.(uint64), req)
+	if err != nil {
+		ctrl.respondCommunityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(group))
+}
+
+func (ctrl *CommunityController) GetGroup(c *gin.Context) {
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	var viewerID uint64
+	if userID, exists := c.Get("userId"); exists {
+		viewerID = userID.(uint64)
+	}
+
+	group, err := ctrl.communityService.GetGroup(viewerID, groupID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "group not exists"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get group failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(group))
+}
+
+func (ctrl *CommunityController) UpdateGroup(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	var req models.UpdateCommunityRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "request param error"))
+		return
+	}
+
+	if err := ctrl.communityService.UpdateGroup(userID.(uint64), groupID, req); err != nil {
+		ctrl.respondCommunityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "update successful"))
+}
+
+func (ctrl *CommunityController) UploadAvatar(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	fileHeader, err := c.FormFile("avatar")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "avatar file is required"))
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "read avatar failed"))
+		return
+	}
+	defer file.Close()
+
+	avatar, err := ctrl.communityService.UploadAvatar(userID.(uint64), groupID, file)
+	if err != nil {
+		ctrl.respondCommunityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(gin.H{"avatar": avatar}))
+}
+
+func (ctrl *CommunityController) DeleteGroup(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	if err := ctrl.communityService.DeleteGroup(userID.(uint64), groupID); err != nil {
+		ctrl.respondCommunityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "delete successful"))
+}
+
+func (ctrl *CommunityController) JoinGroup(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	if err := ctrl.communityService.JoinGroup(userID.(uint64), groupID); err != nil {
+		ctrl.respondCommunityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "join successful"))
+}
+
+func (ctrl *CommunityController) LeaveGroup(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	if err := ctrl.communityService.LeaveGroup(userID.(uint64), groupID); err != nil {
+		ctrl.respondCommunityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "leave successful"))
+}
+
+func (ctrl *CommunityController) InviteUser(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	var req models.CommunityInviteRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "request param error"))
+		return
+	}
+
+	if err := ctrl.communityService.InviteUser(userID.(uint64), groupID, req.ReceiverID); err != nil {
+		ctrl.respondCommunityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "send successful"))
+}
+
+func (ctrl *CommunityController) AcceptInvitation(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	if err := ctrl.communityService.AcceptInvitation(id, userID.(uint64)); err != nil {
+		ctrl.respondCommunityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "accept invitaion"))
+}
+
+func (ctrl *CommunityController) RefuseInvitation(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	if err := ctrl.communityService.RefuseInvitation(id, userID.(uint64)); err != nil {
+		ctrl.respondCommunityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "refuse successful"))
+}
+
+func (ctrl *CommunityController) GetReceivedInvitations(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	page, pageSize := parsePage(c)
+	invitations, err := ctrl.communityService.GetReceivedInvitations(userID.(uint64), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get invitations failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(invitations))
+}
+
+func (ctrl *CommunityController) SetMemberRole(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+	targetID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild userId"))
+		return
+	}
+
+	var req models.CommunityRoleRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "request param error"))
+		return
+	}
+
+	if err := ctrl.communityService.SetMemberRole(userID.(uint64), groupID, targetID, req.Role); err != nil {
+		ctrl.respondCommunityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "update successful"))
+}
+
+func (ctrl *CommunityController) KickMember(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+	targetID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild userId"))
+		return
+	}
+
+	if err := ctrl.communityService.KickMember(userID.(uint64), groupID, targetID); err != nil {
+		ctrl.respondCommunityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "remove successful"))
+}
+
+func (ctrl *CommunityController) GetMembers(c *gin.Context) {
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	page, pageSize := parsePage(c)
+	members, err := ctrl.communityService.GetMembers(groupID, page, pageSize)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "group not exists"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get members failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(members))
+}
+
+// GetUserGroups 路由参数userId为空时返回当前用户自己的群组
+func (ctrl *CommunityController) GetUserGroups(c *gin.Context) {
+	var viewerID uint64
+	if userID, exists := c.Get("userId"); exists {
+		viewerID = userID.(uint64)
+	}
+
+	targetID := viewerID
+	if idStr := c.Param("userId"); idStr != "" {
+		id, err := strconv.ParseUint(idStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild userId"))
+			return
+		}
+		targetID = id
+	}
+	if targetID == 0 {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	page, pageSize := parsePage(c)
+	groups, err := ctrl.communityService.GetUserGroups(viewerID, targetID, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get groups failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(groups))
+}
@@ -0,0 +1,322 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"steam-backend/models"
+	"steam-backend/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CuratorController struct {
+	curatorService services.CuratorService
+}
+
+func NewCuratorController(curatorService services.CuratorService) *CuratorController {
+	return &CuratorController{curatorService: curatorService}
+}
+
+// respondCuratorError 鉴赏家或列表不存在返回404，非创建者操作返回403，其余视为请求错误
+func (ctrl *CuratorController) respondCuratorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "not exists"))
+	case errors.Is(err, services.ErrCuratorForbidden):
+		c.JSON(http.StatusForbidden, models.ForbiddenResponse(nil, err.Error()))
+	default:
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+	}
+}
+
+// parseCuratorListID 解析路径中的鉴赏家ID和列表ID
+func parseCuratorListID(c *gin.Context) (uint64, uint64, bool) {
+	curatorID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return 0, 0, false
+	}
+	listID, err := strconv.ParseUint(c.Param("listId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild listId"))
+		return 0, 0, false
+	}
+	return curatorID, listID, true
+}
+
+func (ctrl *CuratorController) CreateCurator(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	var req models.CreateCuratorRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "request param error"))
+		return
+	}
+
+	curator, err := ctrl.curatorService.CreateCurator(userID.(uint64), req)
+	if err != nil {
+		ctrl.respondCuratorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(curator))
+}
+
+func (ctrl *CuratorController) GetCurator(c *gin.Context) {
+	curatorID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	var viewerID uint64
+	if userID, exists := c.Get("userId"); exists {
+		viewerID = userID.(uint64)
+	}
+
+	curator, err := ctrl.curatorService.GetCurator(viewerID, curatorID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "curator not exists"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get curator failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(curator))
+}
+
+func (ctrl *CuratorController) UpdateCurator(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	curatorID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	var req models.UpdateCuratorRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "request param error"))
+		return
+	}
+
+	if err := ctrl.curatorService.UpdateCurator(userID.(uint64), curatorID, req); err != nil {
+		ctrl.respondCuratorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "update successful"))
+}
+
+// Follow POST关注，DELETE取消关注
+func (ctrl *CuratorController) Follow(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	curatorID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	if c.Request.Method == http.MethodDelete {
+		err = ctrl.curatorService.Unfollow(userID.(uint64), curatorID)
+	} else {
+		err = ctrl.curatorService.Follow(userID.(uint64), curatorID)
+	}
+	if err != nil {
+		ctrl.respondCuratorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(nil))
+}
+
+func (ctrl *CuratorController) GetFollowedCurators(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	page, pageSize := parsePage(c)
+	curators, err := ctrl.curatorService.GetFollowedCurators(userID.(uint64), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get followed curators failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(curators))
+}
+
+func (ctrl *CuratorController) CreateList(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	curatorID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	var req models.CuratorListRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "request param error"))
+		return
+	}
+
+	list, err := ctrl.curatorService.CreateList(userID.(uint64), curatorID, req)
+	if err != nil {
+		ctrl.respondCuratorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(list))
+}
+
+func (ctrl *CuratorController) GetLists(c *gin.Context) {
+	curatorID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	lists, err := ctrl.curatorService.GetLists(curatorID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "curator not exists"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get lists failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(lists))
+}
+
+func (ctrl *CuratorController) GetList(c *gin.Context) {
+	curatorID, listID, ok := parseCuratorListID(c)
+	if !ok {
+		return
+	}
+
+	list, err := ctrl.curatorService.GetList(curatorID, listID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "list not exists"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get list failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(list))
+}
+
+func (ctrl *CuratorController) UpdateList(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	curatorID, listID, ok := parseCuratorListID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CuratorListRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "request param error"))
+		return
+	}
+
+	if err := ctrl.curatorService.UpdateList(userID.(uint64), curatorID, listID, req); err != nil {
+		ctrl.respondCuratorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "update successful"))
+}
+
+func (ctrl *CuratorController) DeleteList(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	curatorID, listID, ok := parseCuratorListID(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.curatorService.DeleteList(userID.(uint64), curatorID, listID); err != nil {
+		ctrl.respondCuratorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "delete successful"))
+}
+
+func (ctrl *CuratorController) SetListItems(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	curatorID, listID, ok := parseCuratorListID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CuratorListItemsRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "request param error"))
+		return
+	}
+
+	if err := ctrl.curatorService.SetListItems(userID.(uint64), curatorID, listID, req.Items); err != nil {
+		ctrl.respondCuratorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "update successful"))
+}
+
+// GetFollowedPicks 商店中"你关注的鉴赏家推荐"栏目
+func (ctrl *CuratorController) GetFollowedPicks(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	page, pageSize := parsePage(c)
+	picks, err := ctrl.curatorService.GetFollowedPicks(userID.(uint64), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get curator picks failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(picks))
+}
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"steam-backend/models"
+	"steam-backend/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+const eventWriteTimeout = 10 * time.Second
+
+type EventController struct {
+	hub          *services.EventHub
+	pingInterval time.Duration
+}
+
+func NewEventController(hub *services.EventHub, pingInterval time.Duration) *EventController {
+	return &EventController{hub: hub, pingInterval: pingInterval}
+}
+
+// pingTicker 心跳间隔<=0时不发送心跳，返回的channel为nil，永远不会触发
+func (ctrl *EventController) pingTicker() (<-chan time.Time, func()) {
+	if ctrl.pingInterval <= 0 {
+		return nil, func() {}
+	}
+	ticker := time.NewTicker(ctrl.pingInterval)
+	return ticker.C, ticker.Stop
+}
+
+// lastEventID SSE重连时浏览器会带上Last-Event-ID请求头，WebSocket通过lastEventId参数传入
+func lastEventID(c *gin.Context) uint64 {
+	value := c.GetHeader("Last-Event-ID")
+	if value == "" {
+		value = c.Query("lastEventId")
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+func (ctrl *EventController) WebSocket(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+	lastID := lastEventID(c)
+
+	//鉴权依赖token而不是cookie，因此不校验Origin
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+
+		sub, missed := ctrl.hub.Subscribe(userID.(uint64), lastID)
+		defer ctrl.hub.Unsubscribe(sub)
+
+		//客户端不会发送业务消息，读循环只用于感知连接关闭
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			var discard string
+			for websocket.Message.Receive(ws, &discard) == nil {
+			}
+		}()
+
+		send := func(v any) bool {
+			ws.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+			return websocket.JSON.Send(ws, v) == nil
+		}
+
+		for _, event := range missed {
+			if !send(event) {
+				return
+			}
+		}
+
+		pings, stopPings := ctrl.pingTicker()
+		defer stopPings()
+		for {
+			select {
+			case event, ok := <-sub.Events():
+				//缓冲区满被断开时关闭连接，客户端带上lastEventId重连即可补发
+				if !ok || !send(event) {
+					return
+				}
+			case <-pings:
+				if !send(gin.H{"type": "ping"}) {
+					return
+				}
+			case <-closed:
+				return
+			}
+		}
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// Stream SSE降级方案，浏览器EventSource断线后会自动重连
+func (ctrl *EventController) Stream(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	sub, missed := ctrl.hub.Subscribe(userID.(uint64), lastEventID(c))
+	defer ctrl.hub.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	for _, event := range missed {
+		if writeSSE(c, event) != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	pings, stopPings := ctrl.pingTicker()
+	defer stopPings()
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok || writeSSE(c, event) != nil {
+				return
+			}
+		case <-pings:
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+		case <-c.Request.Context().Done():
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+func writeSSE(c *gin.Context, event models.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -1,20 +1,23 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"steam-backend/models"
 	"steam-backend/services"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type FriendController struct {
-	friendService services.FriendService
+	friendService   services.FriendService
+	activityService services.ActivityService
 }
 
-func NewFriendController(server services.FriendService) *FriendController {
-	return &FriendController{friendService: server}
+func NewFriendController(server services.FriendService, activityService services.ActivityService) *FriendController {
+	return &FriendController{friendService: server, activityService: activityService}
 }
 
 func (ctrl *FriendController) GetFriendCount(c *gin.Context) {
@@ -24,7 +27,7 @@ func (ctrl *FriendController) GetFriendCount(c *gin.Context) {
 		return
 	}
 
-	count, err := ctrl.friendService.GetFriendCount(userID.(int64))
+	count, err := ctrl.friendService.GetFriendCount(userID.(uint64))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get friendCount failed"))
 		return
@@ -40,23 +43,28 @@ func (ctrl *FriendController) GetFriendList(c *gin.Context) {
 		return
 	}
 
-	list, err := ctrl.friendService.GetFriendList(userID.(int64))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get friendList failed"))
-		return
+	//可选参数groupId，只返回该分组中的好友
+	var groupID uint64
+	if groupStr := c.Query("groupId"); groupStr != "" {
+		id, err := strconv.ParseUint(groupStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild groupId"))
+			return
+		}
+		groupID = id
 	}
 
-	listDto := make([]models.UserDto, len(list))
-	for i, user := range list {
-		listDto[i] = models.UserDto{
-			UserID:   user.UserID,
-			UserName: user.UserName,
-			Avatar:   user.Avatar,
-			NickName: user.NickName,
+	list, err := ctrl.friendService.GetFriendList(userID.(uint64), groupID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "group not exists"))
+			return
 		}
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get friendList failed"))
+		return
 	}
 
-	c.JSON(http.StatusOK, models.SuccessResponse(listDto))
+	c.JSON(http.StatusOK, models.SuccessResponse(list))
 }
 
 func (ctrl *FriendController) SendInvitation(c *gin.Context) {
@@ -72,9 +80,13 @@ func (ctrl *FriendController) SendInvitation(c *gin.Context) {
 		return
 	}
 
-	err := ctrl.friendService.SendInvitation(userID.(uint64), req.ReceiverID)
+	err := ctrl.friendService.SendInvitation(userID.(uint64), req.ReceiverID, req.Message)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "send invitaion failed"))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "user not exists"))
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
 		return
 	}
 
@@ -98,6 +110,7 @@ func (ctrl *FriendController) AcceptInvitation(c *gin.Context) {
 	err = ctrl.friendService.AcceptInvitation(id, userID.(uint64))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "accept invitaion failed"))
+		return
 	}
 
 	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "accept invitaion"))
@@ -126,6 +139,33 @@ func (ctrl *FriendController) RefuseInvitation(c *gin.Context) {
 	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "refuse successful"))
 }
 
+func (ctrl *FriendController) CancelInvitation(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	idstr := c.Param("id")
+	id, err := strconv.ParseUint(idstr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	err = ctrl.friendService.CancelInvitation(id, userID.(uint64))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "invitation not exists"))
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "cancel successful"))
+}
+
 func (ctrl *FriendController) GetReceivedInvitations(c *gin.Context) {
 	userID, exists := c.Get("userId")
 	if !exists {
@@ -133,7 +173,8 @@ func (ctrl *FriendController) GetReceivedInvitations(c *gin.Context) {
 		return
 	}
 
-	invitaions, err := ctrl.friendService.GetReceivedInvitations(userID.(uint64))
+	page, pageSize := parsePage(c)
+	invitaions, err := ctrl.friendService.GetReceivedInvitations(userID.(uint64), page, pageSize)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get received invitaion failed"))
 		return
@@ -149,9 +190,10 @@ func (ctrl *FriendController) GetSentInvitations(c *gin.Context) {
 		return
 	}
 
-	invitaions, err := ctrl.friendService.GetSentInvitations(userID.(uint64))
+	page, pageSize := parsePage(c)
+	invitaions, err := ctrl.friendService.GetSentInvitations(userID.(uint64), page, pageSize)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get received invitaion failed"))
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get sent invitaion failed"))
 		return
 	}
 
@@ -203,3 +245,350 @@ func (ctrl *FriendController) CheckFriendship(c *gin.Context) {
 
 	c.JSON(http.StatusOK, models.SuccessResponse(isFriend))
 }
+
+func (ctrl *FriendController) GetMutualFriends(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	otherID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild userId"))
+		return
+	}
+
+	friends, err := ctrl.friendService.GetMutualFriends(userID.(uint64), otherID)
+	if err != nil {
+		if errors.Is(err, services.ErrPrivacyRestricted) {
+			c.JSON(http.StatusForbidden, models.ForbiddenResponse(nil, err.Error()))
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(friends))
+}
+
+func (ctrl *FriendController) GetSuggestions(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+	if limit > 30 {
+		limit = 30
+	}
+
+	suggestions, err := ctrl.friendService.GetSuggestions(userID.(uint64), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get suggestions failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(suggestions))
+}
+
+func (ctrl *FriendController) BlockUser(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	var req models.BlockRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "request param error"))
+		return
+	}
+
+	err := ctrl.friendService.BlockUser(userID.(uint64), req.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "user not exists"))
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "block successful"))
+}
+
+func (ctrl *FriendController) UnblockUser(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild userId"))
+		return
+	}
+
+	err = ctrl.friendService.UnblockUser(userID.(uint64), targetID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "user not blocked"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "unblock failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "unblock successful"))
+}
+
+func (ctrl *FriendController) GetBlockedList(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	users, err := ctrl.friendService.GetBlockedList(userID.(uint64))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get blocked list failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(users))
+}
+
+func (ctrl *FriendController) CreateGroup(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	var req models.FriendGroupRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "request param error"))
+		return
+	}
+
+	group, err := ctrl.friendService.CreateGroup(userID.(uint64), req.Name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(group))
+}
+
+func (ctrl *FriendController) GetGroups(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	groups, err := ctrl.friendService.GetGroups(userID.(uint64))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get groups failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(groups))
+}
+
+func (ctrl *FriendController) RenameGroup(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	var req models.FriendGroupRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "request param error"))
+		return
+	}
+
+	err = ctrl.friendService.RenameGroup(userID.(uint64), groupID, req.Name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "group not exists"))
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "rename successful"))
+}
+
+func (ctrl *FriendController) DeleteGroup(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	err = ctrl.friendService.DeleteGroup(userID.(uint64), groupID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "group not exists"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "delete group failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "delete successful"))
+}
+
+func (ctrl *FriendController) AddGroupMember(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	var req models.FriendGroupMemberRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "request param error"))
+		return
+	}
+
+	err = ctrl.friendService.AddGroupMember(userID.(uint64), groupID, req.FriendID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "group not exists"))
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "add successful"))
+}
+
+func (ctrl *FriendController) RemoveGroupMember(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+	friendID, err := strconv.ParseUint(c.Param("friendId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild friendId"))
+		return
+	}
+
+	err = ctrl.friendService.RemoveGroupMember(userID.(uint64), groupID, friendID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "group not exists"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "remove member failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "remove successful"))
+}
+
+func (ctrl *FriendController) SetNickname(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	var req models.FriendNicknameRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "request param error"))
+		return
+	}
+
+	err := ctrl.friendService.SetNickname(userID.(uint64), req.FriendID, req.Nickname)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "set nickname successful"))
+}
+
+// GetFeed 好友动态，按cursor向更早的时间翻页
+func (ctrl *FriendController) GetFeed(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	cursor, limit := parseCursor(c)
+	feed, err := ctrl.activityService.GetFeed(userID.(uint64), cursor, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get feed failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(feed))
+}
+
+// GetUserFriendList 查看其他用户的好友列表，未登录也可以访问公开的列表
+func (ctrl *FriendController) GetUserFriendList(c *gin.Context) {
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild userId"))
+		return
+	}
+
+	var viewerID uint64
+	if userID, exists := c.Get("userId"); exists {
+		viewerID = userID.(uint64)
+	}
+
+	friends, err := ctrl.friendService.GetUserFriendList(viewerID, targetID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "user not exists"))
+			return
+		}
+		if errors.Is(err, services.ErrPrivacyRestricted) {
+			c.JSON(http.StatusForbidden, models.ForbiddenResponse(nil, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get friendList failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(friends))
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"steam-backend/models"
+	"steam-backend/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MessageController struct {
+	messageService services.MessageService
+}
+
+func NewMessageController(messageService services.MessageService) *MessageController {
+	return &MessageController{messageService: messageService}
+}
+
+func (ctrl *MessageController) SendMessage(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	var req models.SendMessageRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "request param error"))
+		return
+	}
+
+	message, err := ctrl.messageService.SendMessage(userID.(uint64), req.ReceiverID, req.Content)
+	if err != nil {
+		var limited *services.RateLimitedError
+		if errors.As(err, &limited) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, models.TooManyResponse(nil, "sending too fast"))
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(message))
+}
+
+func (ctrl *MessageController) GetConversation(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	otherID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild userId"))
+		return
+	}
+
+	cursor, limit := parseCursor(c)
+	messages, err := ctrl.messageService.GetConversation(userID.(uint64), otherID, cursor, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get messages failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(messages))
+}
+
+func (ctrl *MessageController) GetConversations(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	page, pageSize := parsePage(c)
+	conversations, err := ctrl.messageService.GetConversations(userID.(uint64), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get conversations failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(conversations))
+}
+
+func (ctrl *MessageController) GetUnreadCount(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	count, err := ctrl.messageService.GetUnreadCount(userID.(uint64))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get unread count failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(count))
+}
+
+func (ctrl *MessageController) MarkRead(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	otherID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild userId"))
+		return
+	}
+
+	if err := ctrl.messageService.MarkRead(userID.(uint64), otherID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "mark read failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(nil))
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"steam-backend/models"
+	"steam-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type OAuthController struct {
+	oauthService services.OAuthService
+}
+
+func NewOAuthController(oauthService services.OAuthService) *OAuthController {
+	return &OAuthController{oauthService: oauthService}
+}
+
+func (ctrl *OAuthController) RegisterClient(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	var req models.OAuthClientRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "param error"))
+		return
+	}
+
+	client, err := ctrl.oauthService.RegisterClient(userID.(uint64), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(client))
+}
+
+func (ctrl *OAuthController) GetClients(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	clients, err := ctrl.oauthService.GetClients(userID.(uint64))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get clients failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(clients))
+}
+
+func (ctrl *OAuthController) DeleteClient(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	err := ctrl.oauthService.DeleteClient(c.Param("clientId"), userID.(uint64))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "client not exists"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "delete client failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "delete successful"))
+}
+
+// GetConsent 前端拿到第三方应用的授权请求后调用，校验参数并返回确认页需要的信息
+func (ctrl *OAuthController) GetConsent(c *gin.Context) {
+	var req models.OAuthAuthorizeRequestDto
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "param error"))
+		return
+	}
+
+	consent, err := ctrl.oauthService.PrepareConsent(&req)
+	if err != nil {
+		ctrl.respondOAuthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(consent))
+}
+
+// Consent 用户同意或拒绝授权，前端跳转到返回的redirectUri
+func (ctrl *OAuthController) Consent(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	var req models.OAuthConsentRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "param error"))
+		return
+	}
+
+	redirect, err := ctrl.oauthService.Authorize(userID.(uint64), &req.OAuthAuthorizeRequestDto, req.Approve)
+	if err != nil {
+		ctrl.respondOAuthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(gin.H{"redirectUri": redirect}))
+}
+
+// Token 供第三方应用的后端调用，请求和响应格式遵循RFC 6749而不是ResponseDto
+func (ctrl *OAuthController) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+
+	var req models.OAuthTokenRequestDto
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "missing parameter"})
+		return
+	}
+
+	token, err := ctrl.oauthService.ExchangeCode(&req)
+	if err != nil {
+		var oauthErr *services.OAuthError
+		if !errors.As(err, &oauthErr) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+			return
+		}
+		status := http.StatusBadRequest
+		if oauthErr.Code == "invalid_client" {
+			status = http.StatusUnauthorized
+		}
+		c.JSON(status, gin.H{"error": oauthErr.Code, "error_description": oauthErr.Description})
+		return
+	}
+
+	c.JSON(http.StatusOK, token)
+}
+
+func (ctrl *OAuthController) respondOAuthError(c *gin.Context, err error) {
+	var oauthErr *services.OAuthError
+	if errors.As(err, &oauthErr) {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(gin.H{"error": oauthErr.Code}, oauthErr.Description))
+		return
+	}
+	c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "authorize failed"))
+}
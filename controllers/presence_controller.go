@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"steam-backend/models"
+	"steam-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PresenceController struct {
+	presenceService services.PresenceService
+}
+
+func NewPresenceController(presenceService services.PresenceService) *PresenceController {
+	return &PresenceController{presenceService: presenceService}
+}
+
+// Heartbeat 客户端需要在PresenceTTL内定期调用，否则会被视为离线
+func (ctrl *PresenceController) Heartbeat(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	var req models.HeartbeatRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "request param error"))
+		return
+	}
+
+	err := ctrl.presenceService.Heartbeat(userID.(uint64), req.Status, req.AppID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "app not exists"))
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(nil))
+}
+
+func (ctrl *PresenceController) GoOffline(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	if err := ctrl.presenceService.GoOffline(userID.(uint64)); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "go offline failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(nil))
+}
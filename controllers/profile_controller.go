@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"steam-backend/models"
+	"steam-backend/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ProfileController struct {
+	profileService services.ProfileService
+}
+
+func NewProfileController(profileService services.ProfileService) *ProfileController {
+	return &ProfileController{profileService: profileService}
+}
+
+func (ctrl *ProfileController) GetProfile(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "无效的用户ID"))
+		return
+	}
+
+	var viewerID uint64
+	if userID, exists := c.Get("userId"); exists {
+		viewerID = userID.(uint64)
+	}
+
+	profile, err := ctrl.profileService.GetProfile(viewerID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "用户不存在"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "获取用户信息失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(profile))
+}
+
+func (ctrl *ProfileController) GetComments(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild userId"))
+		return
+	}
+
+	var viewerID uint64
+	if userID, exists := c.Get("userId"); exists {
+		viewerID = userID.(uint64)
+	}
+
+	page, pageSize := parsePage(c)
+	comments, err := ctrl.profileService.GetComments(viewerID, id, page, pageSize)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "user not exists"))
+			return
+		}
+		if errors.Is(err, services.ErrPrivacyRestricted) {
+			c.JSON(http.StatusForbidden, models.ForbiddenResponse(nil, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get comments failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(comments))
+}
+
+func (ctrl *ProfileController) PostComment(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild userId"))
+		return
+	}
+
+	var req models.ProfileCommentRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "request param error"))
+		return
+	}
+
+	comment, err := ctrl.profileService.PostComment(userID.(uint64), id, req.Content)
+	if err != nil {
+		var limited *services.RateLimitedError
+		if errors.As(err, &limited) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, models.TooManyResponse(nil, "commenting too fast"))
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "user not exists"))
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(comment))
+}
+
+func (ctrl *ProfileController) DeleteComment(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	commentID, err := strconv.ParseUint(c.Param("commentId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild commentId"))
+		return
+	}
+
+	err = ctrl.profileService.DeleteComment(userID.(uint64), commentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "comment not exists"))
+			return
+		}
+		if errors.Is(err, services.ErrCommentForbidden) {
+			c.JSON(http.StatusForbidden, models.ForbiddenResponse(nil, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "delete comment failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "delete successful"))
+}
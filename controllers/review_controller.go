@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"steam-backend/models"
+	"steam-backend/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ReviewController struct {
+	reviewService services.ReviewService
+}
+
+func NewReviewController(reviewService services.ReviewService) *ReviewController {
+	return &ReviewController{reviewService: reviewService}
+}
+
+// GetReviews sort参数为newest(默认)或helpful
+func (ctrl *ReviewController) GetReviews(c *gin.Context) {
+	appID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	sort := c.DefaultQuery("sort", models.ReviewSortNewest)
+	if sort != models.ReviewSortNewest && sort != models.ReviewSortHelpful {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "sort must be newest or helpful"))
+		return
+	}
+
+	page, pageSize := parsePage(c)
+	reviews, err := ctrl.reviewService.GetReviews(appID, sort, page, pageSize)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "app not exists"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get reviews failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(reviews))
+}
+
+func (ctrl *ReviewController) CreateReview(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	appID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	var req models.ReviewRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "request param error"))
+		return
+	}
+
+	review, err := ctrl.reviewService.CreateReview(userID.(uint64), appID, *req.Recommended, req.Content)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "app not exists"))
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(review))
+}
+
+func (ctrl *ReviewController) UpdateReview(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	appID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	var req models.ReviewRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "request param error"))
+		return
+	}
+
+	review, err := ctrl.reviewService.UpdateReview(userID.(uint64), appID, *req.Recommended, req.Content)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "review not exists"))
+			return
+		}
+		if errors.Is(err, services.ErrTextRejected) {
+			c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "update review failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(review))
+}
+
+func (ctrl *ReviewController) DeleteReview(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	appID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild ID"))
+		return
+	}
+
+	if err := ctrl.reviewService.DeleteReview(userID.(uint64), appID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "review not exists"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "delete review failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "delete successful"))
+}
+
+// MarkHelpful PUT标记有帮助，DELETE取消
+func (ctrl *ReviewController) MarkHelpful(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	reviewID, err := strconv.ParseUint(c.Param("reviewId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "invaild reviewId"))
+		return
+	}
+
+	helpful := c.Request.Method != http.MethodDelete
+	if err := ctrl.reviewService.MarkHelpful(userID.(uint64), reviewID, helpful); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "review not exists"))
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(nil))
+}
@@ -1,20 +1,30 @@
 package controllers
 
 import (
+	"errors"
+	"math"
 	"net/http"
 	"steam-backend/models"
 	"steam-backend/services"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type UserController struct {
-	userService services.UserService
+	userService    services.UserService
+	avatarService  services.AvatarService
+	privacyService services.PrivacyService
 }
 
-func NewUserController(service services.UserService) *UserController {
-	return &UserController{userService: service}
+func NewUserController(service services.UserService, avatarService services.AvatarService,
+	privacyService services.PrivacyService) *UserController {
+	return &UserController{
+		userService:    service,
+		avatarService:  avatarService,
+		privacyService: privacyService,
+	}
 }
 
 func (ctrl *UserController) Register(c *gin.Context) {
@@ -26,6 +36,10 @@ func (ctrl *UserController) Register(c *gin.Context) {
 
 	newUser, err := ctrl.userService.Register(&reg)
 	if err != nil {
+		if errors.Is(err, services.ErrTextRejected) {
+			c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "register failed"))
 		return
 	}
@@ -48,9 +62,30 @@ func (ctrl *UserController) Login(c *gin.Context) {
 		return
 	}
 
-	token, user, err := ctrl.userService.Login(&loginDTO)
+	token, user, err := ctrl.userService.Login(&loginDTO, c.ClientIP())
 	if err != nil {
+		var throttled *services.LoginThrottledError
+		if errors.As(err, &throttled) {
+			retryAfter := int(math.Ceil(throttled.RetryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			data := gin.H{"retryAfter": retryAfter}
+			if throttled.Locked {
+				c.JSON(http.StatusLocked, models.LockedResponse(data, "account locked"))
+			} else {
+				c.JSON(http.StatusTooManyRequests, models.TooManyResponse(data, "too many failed attempts"))
+			}
+			return
+		}
+		var banned *services.AccountBannedError
+		if errors.As(err, &banned) {
+			c.JSON(http.StatusForbidden, models.ForbiddenResponse(models.BanDto{
+				Reason:    banned.Ban.Reason,
+				ExpiresAt: banned.Ban.ExpiresAt,
+			}, banned.Error()))
+			return
+		}
 		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+		return
 	}
 
 	resposne := models.LoginResponseDto{
@@ -74,14 +109,143 @@ func (ctrl *UserController) GetUserInfo(c *gin.Context) {
 		return
 	}
 
-	userDTO := models.UserDto{
+	c.JSON(http.StatusOK, models.SuccessResponse(toUserInfoDto(user)))
+}
+
+func (ctrl *UserController) UpdateProfile(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	var req models.UpdateProfileRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "param error"))
+		return
+	}
+
+	user, err := ctrl.userService.UpdateProfile(userID.(uint64), &req)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "user not exists"))
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(toUserInfoDto(user)))
+}
+
+func (ctrl *UserController) ChangeUsername(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	var req models.ChangeUsernameRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "param error"))
+		return
+	}
+
+	user, err := ctrl.userService.ChangeUsername(userID.(uint64), req.UserName)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(toUserInfoDto(user)))
+}
+
+func (ctrl *UserController) GetUsernameHistory(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	history, err := ctrl.userService.GetUsernameHistory(userID.(uint64))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get history failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(history))
+}
+
+func (ctrl *UserController) ChangePassword(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	var req models.ChangePasswordRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "param error"))
+		return
+	}
+
+	err := ctrl.userService.ChangePassword(userID.(uint64), req.OldPassword, req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "password changed, please login again"))
+}
+
+func (ctrl *UserController) UploadAvatar(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	fileHeader, err := c.FormFile("avatar")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "avatar file is required"))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "read avatar failed"))
+		return
+	}
+	defer file.Close()
+
+	urls, err := ctrl.avatarService.UploadAvatar(userID.(uint64), file)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "user not exists"))
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(models.AvatarResponseDto{
+		Avatar: urls[services.AvatarSizes[len(services.AvatarSizes)-1]],
+		Sizes:  urls,
+	}))
+}
+
+func toUserInfoDto(user *models.User) models.UserInfoResponseDto {
+	return models.UserInfoResponseDto{
 		UserID:   user.UserID,
 		UserName: user.UserName,
 		NickName: user.NickName,
 		Avatar:   user.Avatar,
-	}
+		Bio:      user.Bio,
+		Country:  user.Country,
 
-	c.JSON(http.StatusOK, models.SuccessResponse(userDTO))
+		CommentsEnabled:     !user.CommentsDisabled,
+		DeletionScheduledAt: user.DeletionScheduledAt,
+	}
 }
 
 func (ctrl *UserController) CheckUsernameAvailable(c *gin.Context) {
@@ -107,7 +271,13 @@ func (ctrl *UserController) SearchUsers(c *gin.Context) {
 		return
 	}
 
-	users, err := ctrl.userService.SearchUsers(keyword)
+	//登录用户看不到自己屏蔽的人，匿名访问时viewerID为0
+	var viewerID uint64
+	if userID, exists := c.Get("userId"); exists {
+		viewerID = userID.(uint64)
+	}
+
+	users, err := ctrl.userService.SearchUsers(keyword, viewerID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "服务器错误"))
 		return
@@ -126,26 +296,56 @@ func (ctrl *UserController) SearchUsers(c *gin.Context) {
 	c.JSON(http.StatusOK, models.SuccessResponse(userDTOs))
 }
 
-func (ctrl *UserController) GetUserByID(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 64)
+func (ctrl *UserController) GetPrivacySettings(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+		return
+	}
+
+	settings, err := ctrl.privacyService.GetSettings(userID.(uint64))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "无效的用户ID"))
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "get privacy settings failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(settings))
+}
+
+func (ctrl *UserController) UpdatePrivacySettings(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
 		return
 	}
 
-	user, err := ctrl.userService.GetUserInfo(id)
+	var req models.UpdatePrivacyRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "request param error"))
+		return
+	}
+
+	settings, err := ctrl.privacyService.UpdateSettings(userID.(uint64), &req)
 	if err != nil {
-		c.JSON(http.StatusNotFound, models.NotFoundResponse(nil, "用户不存在"))
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "update privacy settings failed"))
 		return
 	}
 
-	userDTO := models.UserDto{
-		UserID:   user.UserID,
-		UserName: user.UserName,
-		Avatar:   user.Avatar,
-		NickName: user.NickName,
+	c.JSON(http.StatusOK, models.SuccessResponse(settings))
+}
+
+func (ctrl *UserController) UnlockAccount(c *gin.Context) {
+	var req models.UnlockAccountRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "param error"))
+		return
+	}
+	if req.UserName == "" && req.IP == "" {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "userName or ip is required"))
+		return
 	}
 
-	c.JSON(http.StatusOK, models.SuccessResponse(userDTO))
+	ctrl.userService.UnlockAccount(req.UserName, req.IP)
+
+	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "unlock successful"))
 }
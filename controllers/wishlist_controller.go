@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"steam-backend/models"
 	"steam-backend/services"
@@ -140,3 +141,29 @@ func (ctrl *WishlistController) SortWishlist(c *gin.Context) {
 
 	c.JSON(http.StatusOK, models.SuccessResponseWithMsg(nil, "愿望清单已排序"))
 }
+
+// GetUserWishlist 查看其他用户的愿望单，未登录也可以访问公开的愿望单
+func (ctrl *WishlistController) GetUserWishlist(c *gin.Context) {
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.BadRequestResponse(nil, "无效的用户ID"))
+		return
+	}
+
+	var viewerID uint64
+	if userID, exists := c.Get("userId"); exists {
+		viewerID = userID.(uint64)
+	}
+
+	wishlist, err := ctrl.wishlistService.GetUserWishlist(viewerID, targetID)
+	if err != nil {
+		if errors.Is(err, services.ErrPrivacyRestricted) {
+			c.JSON(http.StatusForbidden, models.ForbiddenResponse(nil, "对方未公开愿望清单"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "获取愿望清单失败"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse(wishlist))
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"steam-backend/models"
+	"steam-backend/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyMiddleware 供开发者后端调用的接口使用，与AuthMiddleware平行，
+// 通过X-API-Key认证，拒绝被封禁用户的key，检查scope和每个key的限流并记录调用次数
+func APIKeyMiddleware(apiKeyService services.APIKeyService, banChecker BanChecker, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawKey := c.GetHeader("X-API-Key")
+		if rawKey == "" {
+			c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "not offer api key"))
+			c.Abort()
+			return
+		}
+
+		key, err := apiKeyService.Authenticate(rawKey)
+		if err != nil {
+			if errors.Is(err, services.ErrAPIKeyInvalid) {
+				c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "api key is invaild"))
+			} else {
+				c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "check api key failed"))
+			}
+			c.Abort()
+			return
+		}
+
+		if ban := activeBan(banChecker, key.OwnerID); ban != nil {
+			abortBanned(c, ban)
+			return
+		}
+
+		if !hasScope(key.Scopes, scope) {
+			c.JSON(http.StatusForbidden, models.ForbiddenResponse(nil, "insufficient scope: "+scope))
+			c.Abort()
+			return
+		}
+
+		allowed, retryAfter := apiKeyService.Allow(key)
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, models.TooManyResponse(nil, "rate limit exceeded"))
+			c.Abort()
+			return
+		}
+		apiKeyService.RecordUsage(key.ID)
+
+		c.Set("apiKeyId", key.ID)
+		c.Set("apiKeyOwnerId", key.OwnerID)
+		c.Next()
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"log"
 	"net/http"
 	"steam-backend/config"
 	"steam-backend/models"
@@ -10,7 +11,21 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+// TokenChecker 判断签名有效的token是否已被撤销
+type TokenChecker interface {
+	TokenRevoked(claims *utils.Claims) (bool, error)
+}
+
+// AuthChecker 认证中间件在校验签名之后还需要检查的状态
+type AuthChecker interface {
+	BanChecker
+	TokenChecker
+}
+
+// AuthMiddleware resource为空的路由只接受用户本人登录的token；
+// 第三方应用的token需要带有 resource:read(GET/HEAD) 或 resource:write(其余方法) 权限；
+// 已撤销的token返回401，被封禁的用户返回403
+func AuthMiddleware(cfg *config.Config, checker AuthChecker, resource ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -34,15 +49,70 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 				c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "token expired"))
 			case utils.ErrTokenNotValidYet:
 				c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "token not vaild yet"))
-			case utils.ErrTokenInvalid:
+			default:
 				c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "token is invaild"))
 			}
 			c.Abort()
 			return
 		}
 
+		//与封禁检查不同，撤销检查失败时拒绝访问
+		revoked, err := checker.TokenRevoked(claims)
+		if err != nil {
+			log.Printf("check token of user %d failed: %v", claims.UserID, err)
+			c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "check token failed"))
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "token revoked"))
+			c.Abort()
+			return
+		}
+
+		if claims.ClientID != "" {
+			if len(resource) == 0 {
+				c.JSON(http.StatusForbidden, models.ForbiddenResponse(nil, "not available to third-party apps"))
+				c.Abort()
+				return
+			}
+			required := requiredScope(resource[0], c.Request.Method)
+			if !hasScope(claims.Scope, required) {
+				c.JSON(http.StatusForbidden, models.ForbiddenResponse(nil, "insufficient scope: "+required))
+				c.Abort()
+				return
+			}
+			c.Set("clientId", claims.ClientID)
+		}
+
+		if ban := activeBan(checker, claims.UserID); ban != nil {
+			abortBanned(c, ban)
+			return
+		}
+
 		//c.set()将对象存储到gin的上下文，c.next()让请求流转到后续处理，通过c.get()获取存储的对象
 		c.Set("userId", claims.UserID)
+		role := claims.Role
+		if role == "" {
+			role = models.RoleUser
+		}
+		c.Set("role", role)
 		c.Next()
 	}
 }
+
+func requiredScope(resource, method string) string {
+	if method == http.MethodGet || method == http.MethodHead {
+		return resource + ":read"
+	}
+	return resource + ":write"
+}
+
+func hasScope(granted, required string) bool {
+	for _, scope := range strings.Fields(granted) {
+		if scope == required {
+			return true
+		}
+	}
+	return false
+}
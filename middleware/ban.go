@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"steam-backend/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BanChecker 返回用户当前生效的封禁，未被封禁时返回nil
+type BanChecker interface {
+	ActiveBan(userID uint64) (*models.UserBan, error)
+}
+
+// activeBan 查询失败时放行，避免数据库抖动导致所有登录接口不可用
+func activeBan(checker BanChecker, userID uint64) *models.UserBan {
+	ban, err := checker.ActiveBan(userID)
+	if err != nil {
+		log.Printf("check ban of user %d failed: %v", userID, err)
+		return nil
+	}
+	return ban
+}
+
+func abortBanned(c *gin.Context, ban *models.UserBan) {
+	c.JSON(http.StatusForbidden, models.ForbiddenResponse(models.BanDto{
+		Reason:    ban.Reason,
+		ExpiresAt: ban.ExpiresAt,
+	}, "account is banned until "+ban.ExpiresAt.Format(time.RFC3339)))
+	c.Abort()
+}
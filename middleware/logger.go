@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Logger 与gin默认的访问日志格式相同，但会隐去url中的access_token参数，
+// 避免QueryTokenMiddleware接受的token写进日志
+func Logger() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		if param.Latency > time.Minute {
+			param.Latency = param.Latency.Truncate(time.Second)
+		}
+		return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v\n%s",
+			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			param.StatusCode,
+			param.Latency,
+			param.ClientIP,
+			param.Method,
+			redactQueryToken(param.Path),
+			param.ErrorMessage,
+		)
+	})
+}
+
+func redactQueryToken(path string) string {
+	base, rawQuery, ok := strings.Cut(path, "?")
+	if !ok || !strings.Contains(rawQuery, "access_token") {
+		return path
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		//无法解析时整个去掉查询参数
+		return base
+	}
+	if _, exists := query["access_token"]; !exists {
+		return path
+	}
+	query.Set("access_token", "REDACTED")
+	return base + "?" + query.Encode()
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"log"
+	"steam-backend/config"
+	"steam-backend/models"
+	"steam-backend/utils"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OptionalAuthMiddleware 用于匿名也能访问、但登录后结果不同的接口；
+// token缺失、无效或已撤销时按匿名处理，不会拒绝请求。第三方应用的token和被封禁的用户同样按匿名处理
+func OptionalAuthMiddleware(cfg *config.Config, checker AuthChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			claims, err := utils.ParseToken(parts[1], cfg.JWTSecret)
+			if err == nil && claims.ClientID == "" && !tokenRevoked(checker, claims) &&
+				activeBan(checker, claims.UserID) == nil {
+				c.Set("userId", claims.UserID)
+				role := claims.Role
+				if role == "" {
+					role = models.RoleUser
+				}
+				c.Set("role", role)
+			}
+		}
+		c.Next()
+	}
+}
+
+// tokenRevoked 查询失败时同样按匿名处理
+func tokenRevoked(checker TokenChecker, claims *utils.Claims) bool {
+	revoked, err := checker.TokenRevoked(claims)
+	if err != nil {
+		log.Printf("check token of user %d failed: %v", claims.UserID, err)
+		return true
+	}
+	return revoked
+}
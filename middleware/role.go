@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"steam-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RoleChecker 返回用户当前的角色
+type RoleChecker interface {
+	CurrentRole(userID uint64) (string, error)
+}
+
+// RequireRole 需放在AuthMiddleware之后，按数据库中的当前角色判断，
+// 角色被授予或撤销后不必等旧token过期
+func RequireRole(checker RoleChecker, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "unauthorized"))
+			c.Abort()
+			return
+		}
+
+		role, err := checker.CurrentRole(userID.(uint64))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				//token仍在有效期内但用户已被删除
+				c.JSON(http.StatusUnauthorized, models.UnauthorizedResponse(nil, "user not exists"))
+			} else {
+				//与封禁检查不同，权限检查失败时拒绝访问
+				log.Printf("check role of user %d failed: %v", userID, err)
+				c.JSON(http.StatusInternalServerError, models.ServerErrorResponse(nil, "check role failed"))
+			}
+			c.Abort()
+			return
+		}
+		c.Set("role", role)
+
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, models.ForbiddenResponse(nil, "permission denied"))
+		c.Abort()
+	}
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// AccountExportDto 用户可下载的全部个人数据
+type AccountExportDto struct {
+	ExportedAt          time.Time         `json:"exportedAt"`
+	Profile             AccountProfileDto `json:"profile"`
+	UsernameHistory     []UsernameHistory `json:"usernameHistory"`
+	Friends             []UserDto         `json:"friends"`
+	SentInvitations     []Invitation      `json:"sentInvitations"`
+	ReceivedInvitations []Invitation      `json:"receivedInvitations"`
+	Wishlist            []WishlistItemDto `json:"wishlist"`
+	BlockedUsers        []UserDto         `json:"blockedUsers"`
+	AccountRecordsDto
+}
+
+// AccountRecordsDto 注销账号时会被删除的其余数据，与UserRepository.Delete清理的表保持一致
+type AccountRecordsDto struct {
+	PrivacySettings      *PrivacySettings      `json:"privacySettings"`
+	Messages             []Message             `json:"messages"`
+	ProfileComments      []ProfileComment      `json:"profileComments"`
+	Reviews              []Review              `json:"reviews"`
+	HelpfulVotes         []ReviewHelpfulVote   `json:"helpfulVotes"`
+	FriendGroups         []FriendGroup         `json:"friendGroups"`
+	FriendGroupMembers   []FriendGroupMember   `json:"friendGroupMembers"`
+	FriendNicknames      []FriendNickname      `json:"friendNicknames"`
+	Communities          []CommunityGroup      `json:"communities"`
+	CommunityMemberships []CommunityMember     `json:"communityMemberships"`
+	CommunityInvitations []CommunityInvitation `json:"communityInvitations"`
+	Activities           []Activity            `json:"activities"`
+	Curators             []Curator             `json:"curators"`
+	CuratorLists         []CuratorList         `json:"curatorLists"`
+	CuratorListItems     []CuratorListItem     `json:"curatorListItems"`
+	FollowedCurators     []CuratorFollower     `json:"followedCurators"`
+	Bans                 []UserBan             `json:"bans"`
+	Warnings             []UserWarning         `json:"warnings"`
+	OAuthClients         []OAuthClient         `json:"oauthClients"`
+	APIKeys              []APIKey              `json:"apiKeys"`
+}
+
+type AccountProfileDto struct {
+	UserID    uint64    `json:"userId"`
+	Email     string    `json:"email"`
+	UserName  string    `json:"userName"`
+	NickName  string    `json:"nickName"`
+	Avatar    string    `json:"avatar"`
+	Bio       string    `json:"bio"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type DeleteAccountRequestDto struct {
+	Password string `json:"password" binding:"required"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// 好友动态类型
+const (
+	ActivityWishlistAdded  = "wishlist.added"  //TargetID为AppID
+	ActivityFriendAdded    = "friend.added"    //TargetID为新好友的UserID
+	ActivityProfileUpdated = "profile.updated" //TargetID为0
+)
+
+// Activity 用户产生的动态，只有该用户的好友能在动态流中看到
+type Activity struct {
+	ID        uint64    `json:"id" gorm:"primarykey;autoIncrement"`
+	ActorID   uint64    `json:"actorId" gorm:"index"`
+	Type      string    `json:"type" gorm:"size:30;not null"`
+	TargetID  uint64    `json:"targetId" gorm:"default:0"`
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+// ActivityDto 根据Type只会带上App或Friend中的一个
+type ActivityDto struct {
+	ID        uint64    `json:"id"`
+	Actor     UserDto   `json:"actor"`
+	Type      string    `json:"type"`
+	App       *AppDto   `json:"app,omitempty"`
+	Friend    *UserDto  `json:"friend,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ActivityPageDto NextCursor作为下一页的cursor参数，为0表示没有更早的动态
+type ActivityPageDto struct {
+	Activities []ActivityDto `json:"activities"`
+	NextCursor uint64        `json:"nextCursor"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// API key可用的权限，与第三方OAuth的scope相互独立
+const (
+	APIScopeCatalogRead = "catalog:read"
+	APIScopeUsersRead   = "users:read"
+)
+
+var AllAPIScopes = []string{APIScopeCatalogRead, APIScopeUsersRead}
+
+// APIKey 只保存key的sha256，Prefix用于在列表中辨认是哪一个key
+type APIKey struct {
+	ID         uint64     `json:"id" gorm:"primarykey;autoIncrement"`
+	OwnerID    uint64     `json:"ownerId" gorm:"index"`
+	Name       string     `json:"name" gorm:"size:100;not null"`
+	Prefix     string     `json:"prefix" gorm:"size:16"`
+	KeyHash    string     `json:"-" gorm:"size:64;uniqueIndex"`
+	Scopes     string     `json:"-" gorm:"size:255"` //空格分隔
+	RateLimit  int        `json:"rateLimit"`         //每分钟请求数
+	UsageCount int64      `json:"usageCount" gorm:"default:0"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+	CreatedAt  time.Time  `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+type APIKeyRequestDto struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1"`
+}
+
+// APIKeyRateLimitRequestDto 管理员调整key的每分钟请求数
+type APIKeyRateLimitRequestDto struct {
+	RateLimit int `json:"rateLimit" binding:"required,min=1"`
+}
+
+type APIKeyDto struct {
+	ID         uint64     `json:"id"`
+	Key        string     `json:"key,omitempty"` //只在创建时返回一次
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	RateLimit  int        `json:"rateLimit"`
+	UsageCount int64      `json:"usageCount"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
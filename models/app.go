@@ -1,7 +1,9 @@
 package models
 
+import "strings"
+
 type App struct {
-	AppId        uint64  `json:"appId gorm:"primarykey"`
+	AppId        uint64  `json:"appId" gorm:"primarykey"`
 	Name         string  `json:"name" gorm:"size:255;not null"`
 	Description  string  `json:"description" gorm:"type:text"`
 	Price        float64 `json:"price" gorm:"type:decimal(10,2)"`
@@ -10,8 +12,23 @@ type App struct {
 	Developer    string  `json:"developer" gorm:"size:255"`
 	Publisher    string  `json:"publisher" gorm:"size:255"`
 	ImageURL     string  `json:"imageURL" gorm:"size:500"`
-	Tags         string  `json:"tags" gorm:"type:text"`
+	Tags         string  `json:"tags" gorm:"type:text"` //逗号分隔，用SplitTags解析
 	PositiveRate int     `json:"positiveRate" gorm:"default:0"`
+
+	//评测计数，写评测时增量维护，PositiveRate由这两个值计算得出
+	ReviewCount      int64 `json:"reviewCount" gorm:"default:0"`
+	RecommendedCount int64 `json:"recommendedCount" gorm:"default:0"`
+}
+
+// SplitTags 解析App.Tags，兼容逗号和分号分隔，统一转为小写便于比较
+func SplitTags(tags string) []string {
+	var res []string
+	for _, tag := range strings.FieldsFunc(tags, func(r rune) bool { return r == ',' || r == ';' }) {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			res = append(res, tag)
+		}
+	}
+	return res
 }
 
 type AppDto struct {
@@ -21,6 +38,7 @@ type AppDto struct {
 	Discount     float64 `json:"discount" gorm:"type:decimal(5,2)"`
 	ImageURL     string  `json:"imageURL" gorm:"size:500"`
 	PositiveRate int     `json:"positiveRate" gorm:"default:0"`
+	ReviewCount  int64   `json:"reviewCount"`
 }
 
 type RecommendationDto struct {
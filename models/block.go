@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Block 单向屏蔽关系，BlockerID屏蔽了BlockedID
+type Block struct {
+	BlockerID uint64    `json:"blockerId" gorm:"primarykey"`
+	BlockedID uint64    `json:"blockedId" gorm:"primarykey;index"`
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+type BlockRequestDto struct {
+	UserID uint64 `json:"userId" binding:"required"`
+}
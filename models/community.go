@@ -0,0 +1,95 @@
+package models
+
+import "time"
+
+// 社区群组的加入方式
+const (
+	CommunityJoinPublic = "public" //任何人可直接加入
+	CommunityJoinInvite = "invite" //只能通过邀请加入
+)
+
+// 群组成员角色，权限从高到低
+const (
+	CommunityRoleOwner   = "owner"
+	CommunityRoleOfficer = "officer"
+	CommunityRoleMember  = "member"
+)
+
+// CommunityGroup 区别于只对自己可见的好友分组，社区群组是多人共同加入的公开群组
+type CommunityGroup struct {
+	ID          uint64    `json:"id" gorm:"primarykey;autoIncrement"`
+	Name        string    `json:"name" gorm:"size:100;uniqueIndex;not null"`
+	Tag         string    `json:"tag" gorm:"size:12;uniqueIndex;not null"`
+	Avatar      string    `json:"avatar" gorm:"size:500"`
+	Description string    `json:"description" gorm:"type:text"`
+	JoinPolicy  string    `json:"joinPolicy" gorm:"size:20;default:'public'"`
+	OwnerID     uint64    `json:"ownerId" gorm:"index"`
+	MemberCount int64     `json:"memberCount" gorm:"default:0"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+type CommunityMember struct {
+	GroupID  uint64    `json:"groupId" gorm:"primarykey"`
+	UserID   uint64    `json:"userId" gorm:"primarykey;index"`
+	Role     string    `json:"role" gorm:"size:20;default:'member'"`
+	JoinedAt time.Time `json:"joinedAt" gorm:"autoCreateTime"`
+}
+
+// CommunityInvitation 状态沿用好友邀请的InvitationPending等常量
+type CommunityInvitation struct {
+	ID         uint64    `json:"id" gorm:"primarykey;autoIncrement"`
+	GroupID    uint64    `json:"groupId" gorm:"index"`
+	SenderID   uint64    `json:"senderId" gorm:"index"`
+	ReceiverID uint64    `json:"receiverId" gorm:"index"`
+	Status     string    `json:"status" gorm:"size:20;default:'pending'"`
+	CreatedAt  time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+type CreateCommunityRequestDto struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Tag         string `json:"tag" binding:"required,max=12"`
+	Description string `json:"description" binding:"max=2000"`
+	JoinPolicy  string `json:"joinPolicy" binding:"omitempty,oneof=public invite"`
+}
+
+// UpdateCommunityRequestDto 字段为nil表示不修改
+type UpdateCommunityRequestDto struct {
+	Name        *string `json:"name" binding:"omitempty,max=100"`
+	Description *string `json:"description" binding:"omitempty,max=2000"`
+	JoinPolicy  *string `json:"joinPolicy" binding:"omitempty,oneof=public invite"`
+}
+
+type CommunityInviteRequestDto struct {
+	ReceiverID uint64 `json:"receiverId" binding:"required"`
+}
+
+type CommunityRoleRequestDto struct {
+	Role string `json:"role" binding:"required,oneof=officer member"`
+}
+
+type CommunityDto struct {
+	ID          uint64    `json:"id"`
+	Name        string    `json:"name"`
+	Tag         string    `json:"tag"`
+	Avatar      string    `json:"avatar"`
+	Description string    `json:"description"`
+	JoinPolicy  string    `json:"joinPolicy"`
+	OwnerID     uint64    `json:"ownerId"`
+	MemberCount int64     `json:"memberCount"`
+	MyRole      string    `json:"myRole,omitempty"` //当前用户在群组中的角色，未加入时为空
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type CommunityMemberDto struct {
+	User     UserDto   `json:"user"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joinedAt"`
+}
+
+type CommunityInvitationDto struct {
+	ID        uint64       `json:"id"`
+	Group     CommunityDto `json:"group"`
+	Sender    UserDto      `json:"sender"`
+	Status    string       `json:"status"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
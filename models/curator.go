@@ -0,0 +1,117 @@
+package models
+
+import "time"
+
+// 鉴赏家对列表中游戏的评价
+const (
+	CuratorVerdictRecommended    = "recommended"
+	CuratorVerdictInformational  = "informational"
+	CuratorVerdictNotRecommended = "not_recommended"
+)
+
+// Curator 鉴赏家账号，每个用户最多创建一个
+type Curator struct {
+	ID            uint64    `json:"id" gorm:"primarykey;autoIncrement"`
+	OwnerID       uint64    `json:"ownerId" gorm:"uniqueIndex"`
+	Name          string    `json:"name" gorm:"size:100;uniqueIndex;not null"`
+	Description   string    `json:"description" gorm:"type:text"`
+	FollowerCount int64     `json:"followerCount" gorm:"default:0"`
+	CreatedAt     time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+type CuratorList struct {
+	ID          uint64    `json:"id" gorm:"primarykey;autoIncrement"`
+	CuratorID   uint64    `json:"curatorId" gorm:"index"`
+	Title       string    `json:"title" gorm:"size:100;not null"`
+	Description string    `json:"description" gorm:"size:500"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updatedAt" gorm:"autoUpdateTime"`
+}
+
+// CuratorListItem Position从0开始，决定游戏在列表中的顺序
+type CuratorListItem struct {
+	ListID   uint64 `json:"listId" gorm:"primarykey"`
+	AppID    uint64 `json:"appId" gorm:"primarykey;index"`
+	Position int    `json:"position"`
+	Verdict  string `json:"verdict" gorm:"size:20"`
+	Blurb    string `json:"blurb" gorm:"size:300"`
+}
+
+type CuratorFollower struct {
+	CuratorID uint64    `json:"curatorId" gorm:"primarykey"`
+	UserID    uint64    `json:"userId" gorm:"primarykey;index"`
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+type CreateCuratorRequestDto struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=2000"`
+}
+
+// UpdateCuratorRequestDto 字段为nil表示不修改
+type UpdateCuratorRequestDto struct {
+	Name        *string `json:"name" binding:"omitempty,max=100"`
+	Description *string `json:"description" binding:"omitempty,max=2000"`
+}
+
+type CuratorListRequestDto struct {
+	Title       string `json:"title" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=500"`
+}
+
+type CuratorListItemRequestDto struct {
+	AppID   uint64 `json:"appId" binding:"required"`
+	Verdict string `json:"verdict" binding:"required,oneof=recommended informational not_recommended"`
+	Blurb   string `json:"blurb" binding:"max=300"`
+}
+
+// CuratorListItemsRequestDto 整体替换列表内容，数组顺序即展示顺序
+type CuratorListItemsRequestDto struct {
+	Items []CuratorListItemRequestDto `json:"items" binding:"max=100,dive"`
+}
+
+type CuratorDto struct {
+	ID            uint64    `json:"id"`
+	OwnerID       uint64    `json:"ownerId"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	FollowerCount int64     `json:"followerCount"`
+	Following     bool      `json:"following"` //当前用户是否已关注，未登录时为false
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+type CuratorListItemDto struct {
+	App     AppDto `json:"app"`
+	Verdict string `json:"verdict"`
+	Blurb   string `json:"blurb"`
+}
+
+// CuratorListDto 列表概览中Items为空，只有查看单个列表时才返回
+type CuratorListDto struct {
+	ID          uint64               `json:"id"`
+	CuratorID   uint64               `json:"curatorId"`
+	Title       string               `json:"title"`
+	Description string               `json:"description"`
+	Items       []CuratorListItemDto `json:"items,omitempty"`
+	CreatedAt   time.Time            `json:"createdAt"`
+	UpdatedAt   time.Time            `json:"updatedAt"`
+}
+
+type CuratorPickReasonDto struct {
+	CuratorID   uint64 `json:"curatorId"`
+	CuratorName string `json:"curatorName"`
+	Blurb       string `json:"blurb"`
+}
+
+// CuratorPickDto 关注的鉴赏家推荐的游戏，Curators为推荐该游戏的鉴赏家
+type CuratorPickDto struct {
+	App      AppDto                 `json:"app"`
+	Curators []CuratorPickReasonDto `json:"curators"`
+}
+
+// CuratorPickRow 鉴赏家推荐查询的中间结果，一行对应一个鉴赏家对一个游戏的推荐
+type CuratorPickRow struct {
+	AppID     uint64
+	CuratorID uint64
+	Blurb     string
+}
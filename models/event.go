@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// 实时推送的事件类型
+const (
+	EventInvitationReceived = "invitation.received"
+	EventInvitationAccepted = "invitation.accepted"
+	EventFriendOnline       = "friend.online"
+	EventWishlistDiscounted = "wishlist.discounted"
+	EventMessageReceived    = "message.received"
+	EventMessageRead        = "message.read"
+	EventModerationWarning  = "moderation.warning"
+	//重连时错过的事件已超出保留范围，客户端需要重新拉取完整数据
+	EventReset = "reset"
+)
+
+// Event ID全局递增，客户端重连时带上最后收到的ID即可补发错过的事件
+type Event struct {
+	ID        uint64    `json:"id"`
+	Type      string    `json:"type"`
+	Data      any       `json:"data"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type InvitationEventDto struct {
+	InvitationID uint64  `json:"invitationId"`
+	User         UserDto `json:"user"`
+	Message      string  `json:"message,omitempty"`
+}
+
+type FriendOnlineEventDto struct {
+	UserID uint64 `json:"userId"`
+}
+
+type WishlistDiscountEventDto struct {
+	AppID    uint64  `json:"appId"`
+	Name     string  `json:"name"`
+	Price    float64 `json:"price"`
+	Discount float64 `json:"discount"`
+}
+
+// ModerationWarningEventDto 版主警告时推送给被警告的用户
+type ModerationWarningEventDto struct {
+	ReportID uint64 `json:"reportId"`
+	Message  string `json:"message"`
+}
+
+type DiscountRequestDto struct {
+	Discount float64 `json:"discount" binding:"min=0,max=100"`
+}
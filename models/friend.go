@@ -8,10 +8,36 @@ type Friend struct {
 	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
 }
 
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationRefused  = "refused"
+	InvitationCanceled = "canceled" //发送者撤回
+	InvitationExpired  = "expired"  //超过有效期未处理，由后台任务标记
+)
+
 type Invitation struct {
 	ID         uint64    `json:"id" gorm:"primarykey;autoIncrement"`
 	SenderID   uint64    `json:"senderId" gorm:"index"`
 	ReceiverID uint64    `json:"recriverId" gorm:"index"`
-	Status     string    `json:"status" gorm:"size:20;default:'pending'"` //pending,accepted,refused
+	Message    string    `json:"message" gorm:"size:200"`
+	Status     string    `json:"status" gorm:"size:20;default:'pending'"` //pending,accepted,refused,canceled,expired
 	CreatedAt  time.Time `json:"createdAt" gorm:"autoCreateTime"`         //自动填充当前时间
 }
+
+// InvitationDto 列表接口返回，带上双方的用户信息，避免前端逐条查询
+type InvitationDto struct {
+	ID                uint64    `json:"id"`
+	Sender            UserDto   `json:"sender"`
+	Receiver          UserDto   `json:"receiver"`
+	Message           string    `json:"message"`
+	Status            string    `json:"status"`
+	MutualFriendCount int64     `json:"mutualFriendCount"` //当前用户与对方的共同好友数
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+type FriendSuggestionDto struct {
+	User                UserDto `json:"user"`
+	MutualFriendCount   int64   `json:"mutualFriendCount"`
+	SharedWishlistCount int64   `json:"sharedWishlistCount"`
+}
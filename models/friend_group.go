@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// FriendGroup 用户自定义的好友分组，只对创建者可见
+type FriendGroup struct {
+	ID        uint64    `json:"id" gorm:"primarykey;autoIncrement"`
+	OwnerID   uint64    `json:"ownerId" gorm:"index"`
+	Name      string    `json:"name" gorm:"size:50;not null"`
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+type FriendGroupMember struct {
+	GroupID   uint64    `json:"groupId" gorm:"primarykey"`
+	FriendID  uint64    `json:"friendId" gorm:"primarykey;index"`
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+// FriendNickname 用户给好友设置的备注名，只对设置者可见
+type FriendNickname struct {
+	UserID    uint64    `json:"userId" gorm:"primarykey"`
+	FriendID  uint64    `json:"friendId" gorm:"primarykey"`
+	Nickname  string    `json:"nickname" gorm:"size:50"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"autoUpdateTime"`
+}
+
+type FriendGroupRequestDto struct {
+	Name string `json:"name" binding:"required,max=50"`
+}
+
+type FriendGroupMemberRequestDto struct {
+	FriendID uint64 `json:"friendId" binding:"required"`
+}
+
+// FriendNicknameRequestDto Nickname为空表示清除备注
+type FriendNicknameRequestDto struct {
+	FriendID uint64 `json:"friendId" binding:"required"`
+	Nickname string `json:"nickname" binding:"max=50"`
+}
+
+type FriendGroupDto struct {
+	ID        uint64    `json:"id"`
+	Name      string    `json:"name"`
+	MemberIDs []uint64  `json:"memberIds"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// FriendDto 嵌入UserDto，序列化后字段与UserDto保持一致，只多出备注名和在线状态
+type FriendDto struct {
+	UserDto
+	Nickname string      `json:"nickname,omitempty"`
+	Presence PresenceDto `json:"presence"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Message 好友之间的私信，ReadAt为空表示接收方未读
+type Message struct {
+	ID         uint64     `json:"id" gorm:"primarykey;autoIncrement"`
+	SenderID   uint64     `json:"senderId" gorm:"index:idx_message_pair,priority:1"`
+	ReceiverID uint64     `json:"receiverId" gorm:"index:idx_message_pair,priority:2;index"`
+	Content    string     `json:"content" gorm:"type:text;not null"`
+	ReadAt     *time.Time `json:"readAt"`
+	CreatedAt  time.Time  `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+type SendMessageRequestDto struct {
+	ReceiverID uint64 `json:"receiverId" binding:"required"`
+	Content    string `json:"content" binding:"required"`
+}
+
+type MessageDto struct {
+	ID         uint64     `json:"id"`
+	SenderID   uint64     `json:"senderId"`
+	ReceiverID uint64     `json:"receiverId"`
+	Content    string     `json:"content"`
+	ReadAt     *time.Time `json:"readAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// MessagePageDto NextCursor作为下一页的cursor参数，为0表示没有更早的消息
+type MessagePageDto struct {
+	Messages   []MessageDto `json:"messages"`
+	NextCursor uint64       `json:"nextCursor"`
+}
+
+type ConversationDto struct {
+	User        UserDto    `json:"user"`
+	LastMessage MessageDto `json:"lastMessage"`
+	UnreadCount int64      `json:"unreadCount"`
+}
+
+type MessageReadEventDto struct {
+	ReaderID   uint64 `json:"readerId"`
+	LastReadID uint64 `json:"lastReadId"`
+}
@@ -0,0 +1,117 @@
+package models
+
+import "time"
+
+// 可被举报的内容类型，profile/nickname/avatar的TargetID为用户ID
+const (
+	ReportTargetProfile  = "profile"
+	ReportTargetNickname = "nickname"
+	ReportTargetAvatar   = "avatar"
+	ReportTargetReview   = "review"
+	ReportTargetComment  = "comment"
+)
+
+const (
+	ReportReasonSpam          = "spam"
+	ReportReasonHarassment    = "harassment"
+	ReportReasonInappropriate = "inappropriate"
+	ReportReasonCheating      = "cheating"
+	ReportReasonOther         = "other"
+	ReportReasonFiltered      = "filtered" //文本过滤自动提交，举报人ID为0
+)
+
+const (
+	ReportStatusOpen      = "open"
+	ReportStatusResolved  = "resolved"
+	ReportStatusDismissed = "dismissed"
+)
+
+// 版主处理举报的操作
+const (
+	ModerationDismiss = "dismiss" //举报不成立
+	ModerationHide    = "hide"    //移除被举报的内容
+	ModerationWarn    = "warn"    //警告内容发布者
+	ModerationBan     = "ban"     //临时封禁内容发布者
+)
+
+// Report TargetUserID为被举报内容的发布者，举报时确定，便于警告和封禁；
+// 举报人的账号被删除后ReporterID置空，举报本身保留在审核队列中
+type Report struct {
+	ID           uint64     `json:"id" gorm:"primarykey;autoIncrement"`
+	ReporterID   *uint64    `json:"reporterId" gorm:"index"`
+	TargetType   string     `json:"targetType" gorm:"size:20;index:idx_report_target,priority:1"`
+	TargetID     uint64     `json:"targetId" gorm:"index:idx_report_target,priority:2"`
+	TargetUserID uint64     `json:"targetUserId" gorm:"index"`
+	Reason       string     `json:"reason" gorm:"size:20"`
+	Detail       string     `json:"detail" gorm:"size:500"`
+	Status       string     `json:"status" gorm:"size:20;default:'open';index"`
+	ModeratorID  uint64     `json:"moderatorId"`
+	Action       string     `json:"action" gorm:"size:20"`
+	Note         string     `json:"note" gorm:"size:500"`
+	CreatedAt    time.Time  `json:"createdAt" gorm:"autoCreateTime"`
+	ResolvedAt   *time.Time `json:"resolvedAt"`
+}
+
+// UserBan 到期或被版主解除(LiftedAt不为空)后失效
+type UserBan struct {
+	ID          uint64     `json:"id" gorm:"primarykey;autoIncrement"`
+	UserID      uint64     `json:"userId" gorm:"index"`
+	ModeratorID uint64     `json:"moderatorId"`
+	ReportID    uint64     `json:"reportId"`
+	Reason      string     `json:"reason" gorm:"size:500"`
+	ExpiresAt   time.Time  `json:"expiresAt" gorm:"index"`
+	LiftedAt    *time.Time `json:"liftedAt"`
+	CreatedAt   time.Time  `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+type UserWarning struct {
+	ID          uint64    `json:"id" gorm:"primarykey;autoIncrement"`
+	UserID      uint64    `json:"userId" gorm:"index"`
+	ModeratorID uint64    `json:"moderatorId"`
+	ReportID    uint64    `json:"reportId"`
+	Message     string    `json:"message" gorm:"size:500"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+type ReportRequestDto struct {
+	TargetType string `json:"targetType" binding:"required,oneof=profile nickname avatar review comment"`
+	TargetID   uint64 `json:"targetId" binding:"required"`
+	Reason     string `json:"reason" binding:"required,oneof=spam harassment inappropriate cheating other"`
+	Detail     string `json:"detail" binding:"max=500"`
+}
+
+// ReportFilterDto 版主查看举报队列的过滤条件，为空表示不过滤，Status默认为open
+type ReportFilterDto struct {
+	Status     string `form:"status" binding:"omitempty,oneof=open resolved dismissed"`
+	TargetType string `form:"targetType" binding:"omitempty,oneof=profile nickname avatar review comment"`
+	Reason     string `form:"reason" binding:"omitempty,oneof=spam harassment inappropriate cheating other filtered"`
+}
+
+// ResolveReportRequestDto BanHours只在Action为ban时使用
+type ResolveReportRequestDto struct {
+	Action   string `json:"action" binding:"required,oneof=dismiss hide warn ban"`
+	Note     string `json:"note" binding:"max=500"`
+	BanHours int    `json:"banHours" binding:"omitempty,min=1,max=8760"`
+}
+
+type ReportDto struct {
+	ID          uint64     `json:"id"`
+	Reporter    *UserDto   `json:"reporter"` //举报人账号已删除时为null
+	TargetType  string     `json:"targetType"`
+	TargetID    uint64     `json:"targetId"`
+	TargetUser  UserDto    `json:"targetUser"`
+	Reason      string     `json:"reason"`
+	Detail      string     `json:"detail"`
+	Status      string     `json:"status"`
+	ModeratorID uint64     `json:"moderatorId,omitempty"`
+	Action      string     `json:"action,omitempty"`
+	Note        string     `json:"note,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	ResolvedAt  *time.Time `json:"resolvedAt,omitempty"`
+}
+
+// BanDto 被封禁用户访问需要登录的接口时返回
+type BanDto struct {
+	Reason    string    `json:"reason"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
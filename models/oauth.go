@@ -0,0 +1,108 @@
+package models
+
+import "time"
+
+// 第三方应用可申请的权限，前缀与路由组对应：read对应GET请求，write对应其余请求
+const (
+	ScopeProfileRead   = "profile:read"
+	ScopeProfileWrite  = "profile:write"
+	ScopeFriendsRead   = "friends:read"
+	ScopeFriendsWrite  = "friends:write"
+	ScopeWishlistRead  = "wishlist:read"
+	ScopeWishlistWrite = "wishlist:write"
+)
+
+var AllScopes = []string{
+	ScopeProfileRead, ScopeProfileWrite,
+	ScopeFriendsRead, ScopeFriendsWrite,
+	ScopeWishlistRead, ScopeWishlistWrite,
+}
+
+func IsValidScope(scope string) bool {
+	for _, s := range AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// OAuthClient 第三方应用，SecretHash为空表示公开客户端(移动端/单页应用)，只能依赖PKCE
+type OAuthClient struct {
+	ClientID     string    `json:"clientId" gorm:"primarykey;size:64"`
+	SecretHash   string    `json:"-" gorm:"size:60"`
+	Name         string    `json:"name" gorm:"size:100;not null"`
+	OwnerID      uint64    `json:"ownerId" gorm:"index"`
+	RedirectURIs string    `json:"-" gorm:"type:text"` //换行分隔
+	Scopes       string    `json:"-" gorm:"size:255"`  //空格分隔
+	CreatedAt    time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+// OAuthAuthorizationCode 只保存授权码的哈希，授权码一次性且很快过期
+type OAuthAuthorizationCode struct {
+	CodeHash      string    `gorm:"primarykey;size:64"`
+	ClientID      string    `gorm:"size:64;index"`
+	UserID        uint64    `gorm:"index"`
+	RedirectURI   string    `gorm:"size:500"`
+	Scope         string    `gorm:"size:255"`
+	CodeChallenge string    `gorm:"size:128"`
+	Used          bool      `gorm:"default:false"`
+	ExpiresAt     time.Time `gorm:"index"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+}
+
+type OAuthClientRequestDto struct {
+	Name         string   `json:"name" binding:"required,max=100"`
+	RedirectURIs []string `json:"redirectUris" binding:"required,min=1,dive,url"`
+	Scopes       []string `json:"scopes" binding:"required,min=1"`
+	Confidential bool     `json:"confidential"`
+}
+
+type OAuthClientDto struct {
+	ClientID     string    `json:"clientId"`
+	ClientSecret string    `json:"clientSecret,omitempty"` //只在创建时返回一次
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirectUris"`
+	Scopes       []string  `json:"scopes"`
+	Confidential bool      `json:"confidential"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// OAuthAuthorizeRequestDto 字段名遵循RFC 6749/7636
+type OAuthAuthorizeRequestDto struct {
+	ResponseType        string `form:"response_type" json:"response_type" binding:"required"`
+	ClientID            string `form:"client_id" json:"client_id" binding:"required"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri" binding:"required"`
+	Scope               string `form:"scope" json:"scope" binding:"required"`
+	State               string `form:"state" json:"state"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge" binding:"required"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method" binding:"required"`
+}
+
+type OAuthConsentRequestDto struct {
+	OAuthAuthorizeRequestDto
+	Approve bool `json:"approve"`
+}
+
+// OAuthConsentDto 前端据此渲染授权确认页
+type OAuthConsentDto struct {
+	ClientID   string   `json:"clientId"`
+	ClientName string   `json:"clientName"`
+	Scopes     []string `json:"scopes"`
+}
+
+type OAuthTokenRequestDto struct {
+	GrantType    string `form:"grant_type" binding:"required"`
+	Code         string `form:"code" binding:"required"`
+	RedirectURI  string `form:"redirect_uri" binding:"required"`
+	ClientID     string `form:"client_id" binding:"required"`
+	ClientSecret string `form:"client_secret"`
+	CodeVerifier string `form:"code_verifier" binding:"required"`
+}
+
+type OAuthTokenResponseDto struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
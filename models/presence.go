@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// 在线状态，PresenceOffline只用于展示，不会写入存储
+const (
+	PresenceOffline = "offline"
+	PresenceOnline  = "online"
+	PresenceAway    = "away"
+	PresenceInGame  = "in-game"
+)
+
+// Presence 客户端最近一次心跳上报的状态，AppID只在游戏中时有值
+type Presence struct {
+	Status    string
+	AppID     uint64
+	UpdatedAt time.Time
+}
+
+type HeartbeatRequestDto struct {
+	Status string `json:"status" binding:"required,oneof=online away in-game"`
+	AppID  uint64 `json:"appId"`
+}
+
+type PresenceDto struct {
+	Status   string `json:"status"`
+	AppID    uint64 `json:"appId,omitempty"`
+	GameName string `json:"gameName,omitempty"`
+}
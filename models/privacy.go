@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// 隐私级别
+const (
+	PrivacyPublic  = "public"
+	PrivacyFriends = "friends" //仅好友可见
+	PrivacyPrivate = "private" //仅自己可见
+)
+
+// 受隐私设置控制的内容
+const (
+	PrivacyFieldProfile    = "profile"
+	PrivacyFieldFriendList = "friendList"
+	PrivacyFieldWishlist   = "wishlist"
+)
+
+// PrivacySettings 没有记录的用户视为全部公开
+type PrivacySettings struct {
+	UserID     uint64    `json:"userId" gorm:"primarykey"`
+	Profile    string    `json:"profile" gorm:"size:20;default:'public'"`
+	FriendList string    `json:"friendList" gorm:"size:20;default:'public'"`
+	Wishlist   string    `json:"wishlist" gorm:"size:20;default:'public'"`
+	UpdatedAt  time.Time `json:"updatedAt" gorm:"autoUpdateTime"`
+}
+
+type PrivacySettingsDto struct {
+	Profile    string `json:"profile"`
+	FriendList string `json:"friendList"`
+	Wishlist   string `json:"wishlist"`
+}
+
+// UpdatePrivacyRequestDto 字段为nil表示不修改
+type UpdatePrivacyRequestDto struct {
+	Profile    *string `json:"profile" binding:"omitempty,oneof=public friends private"`
+	FriendList *string `json:"friendList" binding:"omitempty,oneof=public friends private"`
+	Wishlist   *string `json:"wishlist" binding:"omitempty,oneof=public friends private"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// ProfileDto 他人查看的个人资料，无权查看详细资料时只返回基本信息并把Restricted置为true，
+// 好友数和愿望单数量分别受好友列表和愿望单的隐私设置控制，不可见时为空
+type ProfileDto struct {
+	UserDto
+	Bio             string     `json:"bio,omitempty"`
+	Country         string     `json:"country,omitempty"`
+	MemberSince     *time.Time `json:"memberSince,omitempty"`
+	FriendCount     *int64     `json:"friendCount,omitempty"`
+	WishlistCount   *int64     `json:"wishlistCount,omitempty"`
+	CommentsEnabled bool       `json:"commentsEnabled"`
+	Restricted      bool       `json:"restricted"`
+}
+
+// ProfileComment 个人主页留言板上的留言
+type ProfileComment struct {
+	ID        uint64    `json:"id" gorm:"primarykey;autoIncrement"`
+	ProfileID uint64    `json:"profileId" gorm:"index"`
+	AuthorID  uint64    `json:"authorId" gorm:"index"`
+	Content   string    `json:"content" gorm:"type:text;not null"`
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+type ProfileCommentRequestDto struct {
+	Content string `json:"content" binding:"required,max=1000"`
+}
+
+type ProfileCommentDto struct {
+	ID        uint64    `json:"id"`
+	Author    UserDto   `json:"author"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -3,7 +3,7 @@ package models
 type ResponseDto struct {
 	Code    int         `json:"code"`
 	Message string      `json:"message"`
-	Data    interface{} `json:"data",omitempty`
+	Data    interface{} `json:"data,omitempty"`
 }
 
 type PageDto struct {
@@ -18,7 +18,10 @@ const (
 	BadRequestCode   = 400
 	UnauthorizedCode = 401
 	NotFoundCode     = 404
+	ForbiddenCode    = 403
 	ConflictCode     = 409
+	LockedCode       = 423
+	TooManyCode      = 429
 	ServerErrorCode  = 500
 )
 
@@ -62,6 +65,30 @@ func ConflictResponse(data interface{}, msg string) ResponseDto {
 	}
 }
 
+func ForbiddenResponse(data interface{}, msg string) ResponseDto {
+	return ResponseDto{
+		Code:    ForbiddenCode,
+		Message: msg,
+		Data:    data,
+	}
+}
+
+func LockedResponse(data interface{}, msg string) ResponseDto {
+	return ResponseDto{
+		Code:    LockedCode,
+		Message: msg,
+		Data:    data,
+	}
+}
+
+func TooManyResponse(data interface{}, msg string) ResponseDto {
+	return ResponseDto{
+		Code:    TooManyCode,
+		Message: msg,
+		Data:    data,
+	}
+}
+
 func NotFoundResponse(data interface{}, msg string) ResponseDto {
 	return ResponseDto{
 		Code:    NotFoundCode,
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// 评测列表排序方式
+const (
+	ReviewSortNewest  = "newest"
+	ReviewSortHelpful = "helpful"
+)
+
+// Review 每个用户对每个游戏只能写一篇评测
+type Review struct {
+	ID           uint64    `json:"id" gorm:"primarykey;autoIncrement"`
+	AppID        uint64    `json:"appId" gorm:"uniqueIndex:idx_review_app_user,priority:1"`
+	UserID       uint64    `json:"userId" gorm:"uniqueIndex:idx_review_app_user,priority:2;index"`
+	Recommended  bool      `json:"recommended"`
+	Content      string    `json:"content" gorm:"type:text"`
+	HelpfulCount int64     `json:"helpfulCount" gorm:"default:0"`
+	CreatedAt    time.Time `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `json:"updatedAt" gorm:"autoUpdateTime"`
+}
+
+// ReviewHelpfulVote 用户认为某篇评测有帮助
+type ReviewHelpfulVote struct {
+	ReviewID  uint64    `json:"reviewId" gorm:"primarykey"`
+	UserID    uint64    `json:"userId" gorm:"primarykey;index"`
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+// ReviewRequestDto Recommended用指针区分未填写和false
+type ReviewRequestDto struct {
+	Recommended *bool  `json:"recommended" binding:"required"`
+	Content     string `json:"content" binding:"max=8000"`
+}
+
+type ReviewDto struct {
+	ID           uint64    `json:"id"`
+	AppID        uint64    `json:"appId"`
+	Author       UserDto   `json:"author"`
+	Recommended  bool      `json:"recommended"`
+	Content      string    `json:"content"`
+	HelpfulCount int64     `json:"helpfulCount"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+	RoleDeveloper = "developer" //可以创建API key，由管理员授予
+)
+
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleUser, RoleModerator, RoleAdmin, RoleDeveloper:
+		return true
+	}
+	return false
+}
+
+// RoleAuditLog 记录每一次角色变更，ActorID为0表示系统根据配置自动授予
+type RoleAuditLog struct {
+	ID        uint64    `json:"id" gorm:"primarykey;autoIncrement"`
+	ActorID   uint64    `json:"actorId" gorm:"index"`
+	TargetID  uint64    `json:"targetId" gorm:"index"`
+	Action    string    `json:"action" gorm:"size:20"` //grant,revoke
+	OldRole   string    `json:"oldRole" gorm:"size:20"`
+	NewRole   string    `json:"newRole" gorm:"size:20"`
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+type RoleRequestDto struct {
+	UserID uint64 `json:"userId" binding:"required"`
+	Role   string `json:"role"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// AppSimilarity 由后台任务根据愿望单共现关系定期重建，每个游戏只保留得分最高的若干个相关游戏
+type AppSimilarity struct {
+	AppID        uint64    `json:"appId" gorm:"primarykey"`
+	RelatedAppID uint64    `json:"relatedAppId" gorm:"primarykey"`
+	Support      int64     `json:"support"` //同时把两个游戏加入愿望单的用户数
+	Score        float64   `json:"score"`   //余弦相似度，避免热门游戏和所有游戏都相关
+	UpdatedAt    time.Time `json:"updatedAt" gorm:"autoUpdateTime"`
+}
+
+// AppWishlistCount 愿望单统计查询的中间结果
+type AppWishlistCount struct {
+	AppID uint64
+	Count int64
+}
@@ -6,11 +6,28 @@ type User struct {
 	UserID    uint64    `json:"userId" gorm:"primarykey;autoIncrement"`
 	Email     string    `json:"email" gorm:"size:255;not null;uniqueIndex"`
 	UserName  string    `json:"userName" gorm:"size:50;uniqueIndex"`
-	PassWord  string    `json:"-" gorm:"size:18"` //json:"-"指定序列化时忽略。存储到数据库时需加密处理
+	PassWord  string    `json:"-" gorm:"size:60"` //json:"-"指定序列化时忽略。存储到数据库时需加密处理,bcrypt结果固定60位
 	NickName  string    `json:"nickName" gorm:"size:50;not null"`
 	Avatar    string    `json:"avatar" gorm:"size:255"`
+	Bio       string    `json:"bio" gorm:"size:500"`
+	Country   string    `json:"country" gorm:"size:2"`              //ISO 3166-1两位国家代码
+	Role      string    `json:"role" gorm:"size:20;default:'user'"` //user,moderator,admin
 	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
 	UpdateAt  time.Time `json:"updateAt" gorm:"autoUpdateTime"`
+
+	UserNameChangedAt   *time.Time `json:"userNameChangedAt"`   //为空表示从未改过用户名
+	DeletionScheduledAt *time.Time `json:"deletionScheduledAt"` //不为空时到期后由后台任务删除账号
+	CommentsDisabled    bool       `json:"commentsDisabled" gorm:"default:false"`
+	PasswordChangedAt   *time.Time `json:"-"` //在此之前签发的token全部失效
+}
+
+// UsernameHistory 用户名变更记录，改名后旧名字即可被他人注册
+type UsernameHistory struct {
+	ID        uint64    `json:"id" gorm:"primarykey;autoIncrement"`
+	UserID    uint64    `json:"userId" gorm:"index"`
+	OldName   string    `json:"oldName" gorm:"size:50;index"`
+	NewName   string    `json:"newName" gorm:"size:50"`
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
 }
 
 type UserDto struct {
@@ -25,6 +42,34 @@ type UserInfoResponseDto struct {
 	UserName string `json:"userName"`
 	NickName string `json:"nickName"`
 	Avatar   string `json:"avatar"`
+	Bio      string `json:"bio"`
+	Country  string `json:"country"`
+
+	CommentsEnabled     bool       `json:"commentsEnabled"`
+	DeletionScheduledAt *time.Time `json:"deletionScheduledAt,omitempty"`
+}
+
+// UpdateProfileRequestDto 字段为nil表示不修改，头像只能通过上传接口修改
+type UpdateProfileRequestDto struct {
+	NickName *string `json:"nickName" binding:"omitempty,min=1,max=50"`
+	Bio      *string `json:"bio" binding:"omitempty,max=500"`
+	Country  *string `json:"country" binding:"omitempty,len=2,alpha"`
+
+	CommentsEnabled *bool `json:"commentsEnabled"`
+}
+
+type AvatarResponseDto struct {
+	Avatar string         `json:"avatar"`
+	Sizes  map[int]string `json:"sizes"` //边长->url
+}
+
+type ChangeUsernameRequestDto struct {
+	UserName string `json:"userName" binding:"required"`
+}
+
+type ChangePasswordRequestDto struct {
+	OldPassword string `json:"oldPassword" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required,min=8,max=72"` //bcrypt只使用前72字节
 }
 
 type LoginRequestDto struct {
@@ -43,8 +88,14 @@ type LoginResponseDto struct {
 	UserID uint64 `json:"userId"`
 }
 
+type UnlockAccountRequestDto struct {
+	UserName string `json:"userName"`
+	IP       string `json:"ip"`
+}
+
 type InvitationRequestDto struct {
-	ReceiverID uint64 `json:"receiverId" binding:"required,min=8,max=16"`
+	ReceiverID uint64 `json:"receiverId" binding:"required"`
+	Message    string `json:"message" binding:"max=200"`
 }
 
 type FriendRequestDto struct {
@@ -52,5 +103,5 @@ type FriendRequestDto struct {
 }
 
 type WishListRequestDto struct {
-	AppID uint64 `json:"appId binding:"required"`
+	AppID uint64 `json:"appId" binding:"required"`
 }
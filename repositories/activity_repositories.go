@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"steam-backend/models"
+
+	"gorm.io/gorm"
+)
+
+type ActivityRepository interface {
+	CreateActivity(activity *models.Activity) error
+	GetFeed(userID, beforeID uint64, limit int) ([]models.Activity, error)
+}
+
+type activityRepository struct {
+	db *gorm.DB
+}
+
+func NewActivityRepository(db *gorm.DB) (ActivityRepository, error) {
+	if db == nil {
+		return nil, errors.New("gorm.DB is nil")
+	}
+	return &activityRepository{db: db}, nil
+}
+
+func (r *activityRepository) CreateActivity(activity *models.Activity) error {
+	return r.db.Create(activity).Error
+}
+
+// GetFeed 按id倒序返回userID所有好友的动态，beforeID为0时从最新一条开始
+func (r *activityRepository) GetFeed(userID, beforeID uint64, limit int) ([]models.Activity, error) {
+	var res []models.Activity
+	query := r.db.Where("actorId IN ("+friendIDsSQL+")", sql.Named("me", userID))
+	if beforeID > 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+	err := query.Order("id DESC").Limit(limit).Find(&res).Error
+	return res, err
+}
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"errors"
+	"steam-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	Create(key *models.APIKey) error
+	FindByHash(keyHash string) (*models.APIKey, error)
+	GetByOwner(ownerID uint64) ([]models.APIKey, error)
+	CountActiveByOwner(ownerID uint64) (int64, error)
+	Revoke(id, ownerID uint64) error
+	UpdateRateLimit(id uint64, rateLimit int) error
+	AddUsage(id uint64, count int64, lastUsedAt time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) (APIKeyRepository, error) {
+	if db == nil {
+		return nil, errors.New("db to apiKeyRepository is nil")
+	}
+	return &apiKeyRepository{db: db}, nil
+}
+
+func (r *apiKeyRepository) Create(key *models.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *apiKeyRepository) FindByHash(keyHash string) (*models.APIKey, error) {
+	var res models.APIKey
+	err := r.db.Where("keyHash = ? and revokedAt is null", keyHash).First(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *apiKeyRepository) GetByOwner(ownerID uint64) ([]models.APIKey, error) {
+	var res []models.APIKey
+	err := r.db.Where("ownerId = ?", ownerID).Order("createdAt DESC").Find(&res).Error
+	return res, err
+}
+
+func (r *apiKeyRepository) CountActiveByOwner(ownerID uint64) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.APIKey{}).Where("ownerId = ? and revokedAt is null", ownerID).Count(&count).Error
+	return count, err
+}
+
+func (r *apiKeyRepository) Revoke(id, ownerID uint64) error {
+	res := r.db.Model(&models.APIKey{}).Where("id = ? and ownerId = ? and revokedAt is null", id, ownerID).Update(
+		"revokedAt", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UpdateRateLimit key不存在或已撤销时返回gorm.ErrRecordNotFound
+func (r *apiKeyRepository) UpdateRateLimit(id uint64, rateLimit int) error {
+	var key models.APIKey
+	if err := r.db.Where("id = ? and revokedAt is null", id).First(&key).Error; err != nil {
+		return err
+	}
+	return r.db.Model(&key).Update("rateLimit", rateLimit).Error
+}
+
+// AddUsage 累加调用次数，由后台任务批量写入，避免每次请求都更新数据库
+func (r *apiKeyRepository) AddUsage(id uint64, count int64, lastUsedAt time.Time) error {
+	return r.db.Model(&models.APIKey{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"usageCount": gorm.Expr("usageCount + ?", count),
+		"lastUsedAt": lastUsedAt,
+	}).Error
+}
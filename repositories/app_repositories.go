@@ -9,10 +9,13 @@ import (
 
 type AppRepository interface {
 	FindByID(id uint64) (*models.App, error)
+	FindByIDs(ids []uint64) ([]models.App, error)
 	FindRecommendations(limit int) ([]models.App, error)
+	FindCandidates(tags []string, excludeIDs []uint64, limit int) ([]models.App, error)
 	FindSpecials(limit int) ([]models.App, error)
 	SearchSuggestions(key string, limit int) ([]models.App, error)
 	SearchApps(key string, page, pageSize int) ([]models.App, int64, error)
+	UpdateDiscount(id uint64, discount float64) error
 }
 
 type appRepository struct {
@@ -36,6 +39,15 @@ func (r *appRepository) FindByID(id uint64) (*models.App, error) {
 	return &res, nil
 }
 
+func (r *appRepository) FindByIDs(ids []uint64) ([]models.App, error) {
+	var res []models.App
+	if len(ids) == 0 {
+		return res, nil
+	}
+	err := r.db.Where("appId IN ?", ids).Find(&res).Error
+	return res, err
+}
+
 func (r *appRepository) FindRecommendations(limit int) ([]models.App, error) {
 	var res []models.App
 
@@ -52,6 +64,26 @@ func (r *appRepository) FindRecommendations(limit int) ([]models.App, error) {
 	return res, nil
 }
 
+// FindCandidates 标签中包含任一tags的游戏，按好评率排序；tags为空时不按标签过滤。
+// 标签用LIKE粗筛，可能匹配到名称相近的标签，调用方需要再精确比较
+func (r *appRepository) FindCandidates(tags []string, excludeIDs []uint64, limit int) ([]models.App, error) {
+	var res []models.App
+
+	query := r.db.Model(&models.App{})
+	if len(tags) > 0 {
+		cond := r.db.Where("tags LIKE ?", "%"+tags[0]+"%")
+		for _, tag := range tags[1:] {
+			cond = cond.Or("tags LIKE ?", "%"+tag+"%")
+		}
+		query = query.Where(cond)
+	}
+	if len(excludeIDs) > 0 {
+		query = query.Where("appId NOT IN ?", excludeIDs)
+	}
+	err := query.Order("positiveRate DESC").Limit(limit).Find(&res).Error
+	return res, err
+}
+
 func (r *appRepository) FindSpecials(limit int) ([]models.App, error) {
 	var res []models.App
 
@@ -91,10 +123,14 @@ func (r *appRepository) SearchApps(key string, page, pageSize int) ([]models.App
 	}
 
 	offset := (page - 1) * pageSize
-	query = query.Order("positiveRare DESC").Limit(pageSize).Offset(offset)
+	query = query.Order("positiveRate DESC").Limit(pageSize).Offset(offset)
 	err = query.Find(&res).Error
 	if err != nil {
 		return nil, 0, err
 	}
 	return res, resCounts, nil
 }
+
+func (r *appRepository) UpdateDiscount(id uint64, discount float64) error {
+	return r.db.Model(&models.App{}).Where("appId = ?", id).Update("discount", discount).Error
+}
@@ -0,0 +1,269 @@
+package repositories
+
+import (
+	"errors"
+	"steam-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CommunityRepository interface {
+	CreateGroup(group *models.CommunityGroup) error
+	FindGroup(id uint64) (*models.CommunityGroup, error)
+	FindGroupsByIDs(ids []uint64) ([]models.CommunityGroup, error)
+	IsNameOrTagTaken(name, tag string, excludeID uint64) (bool, error)
+	UpdateGroup(group *models.CommunityGroup) error
+	DeleteGroup(id uint64) error
+	FindMember(groupID, userID uint64) (*models.CommunityMember, error)
+	GetRoles(userID uint64, groupIDs []uint64) (map[uint64]string, error)
+	AddMember(groupID, userID uint64, role string) error
+	RemoveMember(groupID, userID uint64) error
+	UpdateMemberRole(groupID, userID uint64, role string) error
+	PageMembers(groupID uint64, page, pageSize int) ([]models.CommunityMember, int64, error)
+	PageUserGroups(userID uint64, page, pageSize int) ([]models.CommunityGroup, int64, error)
+	CreateInvitation(invitation *models.CommunityInvitation) error
+	FindPendingInvitation(groupID, receiverID uint64) (*models.CommunityInvitation, error)
+	PageInvitationsByReceiver(receiverID uint64, status string, page, pageSize int) ([]models.CommunityInvitation, int64, error)
+	AcceptInvitation(invitationID, userID uint64) error
+	RefuseInvitation(invitationID, userID uint64) error
+}
+
+type communityRepository struct {
+	db *gorm.DB
+}
+
+func NewCommunityRepository(db *gorm.DB) (CommunityRepository, error) {
+	if db == nil {
+		return nil, errors.New("gorm.DB is nil")
+	}
+	return &communityRepository{db: db}, nil
+}
+
+// CreateGroup 创建群组的同时把创建者加为群主
+func (r *communityRepository) CreateGroup(group *models.CommunityGroup) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		group.MemberCount = 1
+		if err := tx.Create(group).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.CommunityMember{
+			GroupID: group.ID,
+			UserID:  group.OwnerID,
+			Role:    models.CommunityRoleOwner,
+		}).Error
+	})
+}
+
+func (r *communityRepository) FindGroup(id uint64) (*models.CommunityGroup, error) {
+	var res models.CommunityGroup
+	if err := r.db.Where("id = ?", id).First(&res).Error; err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *communityRepository) FindGroupsByIDs(ids []uint64) ([]models.CommunityGroup, error) {
+	var res []models.CommunityGroup
+	if len(ids) == 0 {
+		return res, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&res).Error
+	return res, err
+}
+
+// IsNameOrTagTaken excludeID用于修改群组信息时排除自己
+func (r *communityRepository) IsNameOrTagTaken(name, tag string, excludeID uint64) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.CommunityGroup{}).Where("(name = ? or tag = ?) and id <> ?", name, tag, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// UpdateGroup 只更新资料字段，避免覆盖并发修改的成员数
+func (r *communityRepository) UpdateGroup(group *models.CommunityGroup) error {
+	return r.db.Model(group).Select("name", "description", "joinPolicy", "avatar").Updates(group).Error
+}
+
+func (r *communityRepository) DeleteGroup(id uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("groupId = ?", id).Delete(&models.CommunityMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("groupId = ?", id).Delete(&models.CommunityInvitation{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.CommunityGroup{}).Error
+	})
+}
+
+func (r *communityRepository) FindMember(groupID, userID uint64) (*models.CommunityMember, error) {
+	var res models.CommunityMember
+	if err := r.db.Where("groupId = ? and userId = ?", groupID, userID).First(&res).Error; err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GetRoles 返回userID在各群组中的角色，未加入的群组不在结果中
+func (r *communityRepository) GetRoles(userID uint64, groupIDs []uint64) (map[uint64]string, error) {
+	res := make(map[uint64]string, len(groupIDs))
+	if len(groupIDs) == 0 {
+		return res, nil
+	}
+	var members []models.CommunityMember
+	if err := r.db.Where("userId = ? and groupId IN ?", userID, groupIDs).Find(&members).Error; err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		res[member.GroupID] = member.Role
+	}
+	return res, nil
+}
+
+func (r *communityRepository) AddMember(groupID, userID uint64, role string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return addCommunityMember(tx, groupID, userID, role)
+	})
+}
+
+// addCommunityMember 已经是成员时不做任何修改，新加入时成员数加一
+func addCommunityMember(tx *gorm.DB, groupID, userID uint64, role string) error {
+	res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.CommunityMember{
+		GroupID: groupID,
+		UserID:  userID,
+		Role:    role,
+	})
+	if res.Error != nil || res.RowsAffected == 0 {
+		return res.Error
+	}
+	return tx.Model(&models.CommunityGroup{}).Where("id = ?", groupID).
+		Update("memberCount", gorm.Expr("memberCount + 1")).Error
+}
+
+func (r *communityRepository) RemoveMember(groupID, userID uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("groupId = ? and userId = ?", groupID, userID).Delete(&models.CommunityMember{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Model(&models.CommunityGroup{}).Where("id = ?", groupID).
+			Update("memberCount", gorm.Expr("memberCount - 1")).Error
+	})
+}
+
+func (r *communityRepository) UpdateMemberRole(groupID, userID uint64, role string) error {
+	return r.db.Model(&models.CommunityMember{}).Where("groupId = ? and userId = ?", groupID, userID).
+		Update("role", role).Error
+}
+
+// PageMembers 群主、管理员排在前面，同角色按加入时间排序
+func (r *communityRepository) PageMembers(groupID uint64, page, pageSize int) ([]models.CommunityMember, int64, error) {
+	var res []models.CommunityMember
+	var total int64
+
+	query := r.db.Model(&models.CommunityMember{}).Where("groupId = ?", groupID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order(clause.Expr{SQL: "FIELD(role, ?, ?, ?)", Vars: []interface{}{
+		models.CommunityRoleOwner, models.CommunityRoleOfficer, models.CommunityRoleMember}}).
+		Order("joinedAt").Limit(pageSize).Offset((page - 1) * pageSize).Find(&res).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return res, total, nil
+}
+
+func (r *communityRepository) PageUserGroups(userID uint64, page, pageSize int) ([]models.CommunityGroup, int64, error) {
+	var res []models.CommunityGroup
+	var total int64
+
+	query := r.db.Model(&models.CommunityGroup{}).
+		Where("id IN (SELECT groupId FROM community_members WHERE userId = ?)", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("memberCount DESC").Limit(pageSize).Offset((page - 1) * pageSize).Find(&res).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return res, total, nil
+}
+
+func (r *communityRepository) CreateInvitation(invitation *models.CommunityInvitation) error {
+	return r.db.Create(invitation).Error
+}
+
+func (r *communityRepository) FindPendingInvitation(groupID, receiverID uint64) (*models.CommunityInvitation, error) {
+	var res models.CommunityInvitation
+	err := r.db.Where("groupId = ? and receiverId = ? and status = ?", groupID, receiverID,
+		models.InvitationPending).First(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *communityRepository) PageInvitationsByReceiver(receiverID uint64, status string, page, pageSize int) (
+	[]models.CommunityInvitation, int64, error) {
+	var res []models.CommunityInvitation
+	var total int64
+
+	query := r.db.Model(&models.CommunityInvitation{}).Where("receiverId = ?", receiverID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("createdAt DESC").Limit(pageSize).Offset((page - 1) * pageSize).Find(&res).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return res, total, nil
+}
+
+// pendingCommunityInvitation 在事务中取出属于userID且仍待处理的邀请
+func pendingCommunityInvitation(tx *gorm.DB, invitationID, userID uint64) (*models.CommunityInvitation, error) {
+	var invitation models.CommunityInvitation
+	if err := tx.First(&invitation, invitationID).Error; err != nil {
+		return nil, err
+	}
+	if invitation.ReceiverID != userID {
+		return nil, errors.New("no authorize")
+	}
+	if invitation.Status != models.InvitationPending {
+		return nil, errors.New("invitaion was done")
+	}
+	return &invitation, nil
+}
+
+func (r *communityRepository) AcceptInvitation(invitationID, userID uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		invitation, err := pendingCommunityInvitation(tx, invitationID, userID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(invitation).Update("status", models.InvitationAccepted).Error; err != nil {
+			return err
+		}
+		return addCommunityMember(tx, invitation.GroupID, userID, models.CommunityRoleMember)
+	})
+}
+
+func (r *communityRepository) RefuseInvitation(invitationID, userID uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		invitation, err := pendingCommunityInvitation(tx, invitationID, userID)
+		if err != nil {
+			return err
+		}
+		return tx.Model(invitation).Update("status", models.InvitationRefused).Error
+	})
+}
@@ -0,0 +1,228 @@
+package repositories
+
+import (
+	"errors"
+	"steam-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CuratorRepository interface {
+	CreateCurator(curator *models.Curator) error
+	FindCurator(id uint64) (*models.Curator, error)
+	FindCuratorByOwner(ownerID uint64) (*models.Curator, error)
+	FindCuratorsByIDs(ids []uint64) ([]models.Curator, error)
+	IsNameTaken(name string, excludeID uint64) (bool, error)
+	UpdateCurator(curator *models.Curator) error
+	Follow(curatorID, userID uint64) error
+	Unfollow(curatorID, userID uint64) error
+	IsFollowing(curatorID, userID uint64) (bool, error)
+	PageFollowedCurators(userID uint64, page, pageSize int) ([]models.Curator, int64, error)
+	CreateList(list *models.CuratorList) error
+	FindList(id uint64) (*models.CuratorList, error)
+	GetLists(curatorID uint64) ([]models.CuratorList, error)
+	UpdateList(list *models.CuratorList) error
+	DeleteList(id uint64) error
+	ReplaceItems(listID uint64, items []models.CuratorListItem) error
+	GetItems(listID uint64) ([]models.CuratorListItem, error)
+	PageFollowedPicks(userID uint64, page, pageSize int) ([]uint64, int64, error)
+	GetFollowedPickRows(userID uint64, appIDs []uint64) ([]models.CuratorPickRow, error)
+}
+
+type curatorRepository struct {
+	db *gorm.DB
+}
+
+func NewCuratorRepository(db *gorm.DB) (CuratorRepository, error) {
+	if db == nil {
+		return nil, errors.New("gorm.DB is nil")
+	}
+	return &curatorRepository{db: db}, nil
+}
+
+func (r *curatorRepository) CreateCurator(curator *models.Curator) error {
+	return r.db.Create(curator).Error
+}
+
+func (r *curatorRepository) FindCurator(id uint64) (*models.Curator, error) {
+	var res models.Curator
+	if err := r.db.Where("id = ?", id).First(&res).Error; err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *curatorRepository) FindCuratorByOwner(ownerID uint64) (*models.Curator, error) {
+	var res models.Curator
+	if err := r.db.Where("ownerId = ?", ownerID).First(&res).Error; err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *curatorRepository) FindCuratorsByIDs(ids []uint64) ([]models.Curator, error) {
+	var res []models.Curator
+	if len(ids) == 0 {
+		return res, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&res).Error
+	return res, err
+}
+
+// IsNameTaken excludeID用于修改鉴赏家信息时排除自己
+func (r *curatorRepository) IsNameTaken(name string, excludeID uint64) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Curator{}).Where("name = ? and id <> ?", name, excludeID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *curatorRepository) UpdateCurator(curator *models.Curator) error {
+	return r.db.Model(curator).Select("name", "description").Updates(curator).Error
+}
+
+// Follow 重复关注不会重复计数
+func (r *curatorRepository) Follow(curatorID, userID uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.CuratorFollower{
+			CuratorID: curatorID,
+			UserID:    userID,
+		})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		return tx.Model(&models.Curator{}).Where("id = ?", curatorID).
+			Update("followerCount", gorm.Expr("followerCount + 1")).Error
+	})
+}
+
+func (r *curatorRepository) Unfollow(curatorID, userID uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("curatorId = ? and userId = ?", curatorID, userID).Delete(&models.CuratorFollower{})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		return tx.Model(&models.Curator{}).Where("id = ?", curatorID).
+			Update("followerCount", gorm.Expr("followerCount - 1")).Error
+	})
+}
+
+func (r *curatorRepository) IsFollowing(curatorID, userID uint64) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.CuratorFollower{}).Where("curatorId = ? and userId = ?", curatorID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *curatorRepository) PageFollowedCurators(userID uint64, page, pageSize int) ([]models.Curator, int64, error) {
+	var res []models.Curator
+	var total int64
+
+	query := r.db.Model(&models.Curator{}).
+		Where("id IN (SELECT curatorId FROM curator_followers WHERE userId = ?)", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("followerCount DESC").Limit(pageSize).Offset((page - 1) * pageSize).Find(&res).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return res, total, nil
+}
+
+func (r *curatorRepository) CreateList(list *models.CuratorList) error {
+	return r.db.Create(list).Error
+}
+
+func (r *curatorRepository) FindList(id uint64) (*models.CuratorList, error) {
+	var res models.CuratorList
+	if err := r.db.Where("id = ?", id).First(&res).Error; err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *curatorRepository) GetLists(curatorID uint64) ([]models.CuratorList, error) {
+	var res []models.CuratorList
+	err := r.db.Where("curatorId = ?", curatorID).Order("updatedAt DESC").Find(&res).Error
+	return res, err
+}
+
+func (r *curatorRepository) UpdateList(list *models.CuratorList) error {
+	return r.db.Model(list).Select("title", "description").Updates(list).Error
+}
+
+func (r *curatorRepository) DeleteList(id uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("listId = ?", id).Delete(&models.CuratorListItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.CuratorList{}).Error
+	})
+}
+
+// ReplaceItems 整体替换列表内容，同时刷新列表的更新时间
+func (r *curatorRepository) ReplaceItems(listID uint64, items []models.CuratorListItem) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("listId = ?", listID).Delete(&models.CuratorListItem{}).Error; err != nil {
+			return err
+		}
+		if len(items) > 0 {
+			if err := tx.Create(&items).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.CuratorList{}).Where("id = ?", listID).Update("updatedAt", gorm.Expr("NOW()")).Error
+	})
+}
+
+func (r *curatorRepository) GetItems(listID uint64) ([]models.CuratorListItem, error) {
+	var res []models.CuratorListItem
+	err := r.db.Where("listId = ?", listID).Order("position").Find(&res).Error
+	return res, err
+}
+
+// followedPicksQuery 用户关注的鉴赏家给出推荐评价的游戏，只保留商店中仍存在的游戏
+func (r *curatorRepository) followedPicksQuery(userID uint64) *gorm.DB {
+	return r.db.Table("curator_list_items AS i").
+		Joins("JOIN curator_lists AS l ON l.id = i.listId").
+		Joins("JOIN curator_followers AS f ON f.curatorId = l.curatorId").
+		Joins("JOIN apps AS a ON a.appId = i.appId").
+		Where("f.userId = ? and i.verdict = ?", userID, models.CuratorVerdictRecommended)
+}
+
+// PageFollowedPicks 按推荐该游戏的鉴赏家数量排序，数量相同时按好评率排序
+func (r *curatorRepository) PageFollowedPicks(userID uint64, page, pageSize int) ([]uint64, int64, error) {
+	var total int64
+	err := r.followedPicksQuery(userID).Select("COUNT(DISTINCT i.appId)").Scan(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var res []uint64
+	err = r.followedPicksQuery(userID).
+		Select("i.appId").
+		Group("i.appId, a.positiveRate").
+		Order("COUNT(DISTINCT l.curatorId) DESC, a.positiveRate DESC, i.appId").
+		Limit(pageSize).Offset((page-1)*pageSize).
+		Pluck("i.appId", &res).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return res, total, nil
+}
+
+// GetFollowedPickRows 同一鉴赏家可能在多个列表中推荐同一游戏，由调用方去重
+func (r *curatorRepository) GetFollowedPickRows(userID uint64, appIDs []uint64) ([]models.CuratorPickRow, error) {
+	var res []models.CuratorPickRow
+	if len(appIDs) == 0 {
+		return res, nil
+	}
+	err := r.followedPicksQuery(userID).
+		Select("i.appId AS app_id, l.curatorId AS curator_id, i.blurb AS blurb").
+		Where("i.appId IN ?", appIDs).
+		Order("l.updatedAt DESC").
+		Scan(&res).Error
+	return res, err
+}
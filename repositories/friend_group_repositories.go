@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"errors"
+	"steam-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FriendGroupRepository interface {
+	CreateGroup(group *models.FriendGroup) error
+	FindGroup(groupID, ownerID uint64) (*models.FriendGroup, error)
+	GetGroups(ownerID uint64) ([]models.FriendGroup, error)
+	CountGroups(ownerID uint64) (int64, error)
+	RenameGroup(groupID, ownerID uint64, name string) error
+	DeleteGroup(groupID, ownerID uint64) error
+	AddMember(groupID, friendID uint64) error
+	RemoveMember(groupID, friendID uint64) error
+	GetMembers(groupIDs []uint64) ([]models.FriendGroupMember, error)
+	SetNickname(userID, friendID uint64, nickname string) error
+	GetNicknames(userID uint64) (map[uint64]string, error)
+}
+
+type friendGroupRepository struct {
+	db *gorm.DB
+}
+
+func NewFriendGroupRepository(db *gorm.DB) (FriendGroupRepository, error) {
+	if db == nil {
+		return nil, errors.New("db to friendGroupRepository is nil")
+	}
+	return &friendGroupRepository{db: db}, nil
+}
+
+func (r *friendGroupRepository) CreateGroup(group *models.FriendGroup) error {
+	return r.db.Create(group).Error
+}
+
+func (r *friendGroupRepository) FindGroup(groupID, ownerID uint64) (*models.FriendGroup, error) {
+	var res models.FriendGroup
+	err := r.db.Where("id = ? and ownerId = ?", groupID, ownerID).First(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *friendGroupRepository) GetGroups(ownerID uint64) ([]models.FriendGroup, error) {
+	var res []models.FriendGroup
+	err := r.db.Where("ownerId = ?", ownerID).Order("createdAt").Find(&res).Error
+	return res, err
+}
+
+func (r *friendGroupRepository) CountGroups(ownerID uint64) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.FriendGroup{}).Where("ownerId = ?", ownerID).Count(&count).Error
+	return count, err
+}
+
+// RenameGroup 新名字与原名相同时MySQL不计入影响行数，因此先查询分组是否存在
+func (r *friendGroupRepository) RenameGroup(groupID, ownerID uint64, name string) error {
+	if _, err := r.FindGroup(groupID, ownerID); err != nil {
+		return err
+	}
+	return r.db.Model(&models.FriendGroup{}).Where("id = ? and ownerId = ?", groupID, ownerID).Update("name", name).Error
+}
+
+func (r *friendGroupRepository) DeleteGroup(groupID, ownerID uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? and ownerId = ?", groupID, ownerID).Delete(&models.FriendGroup{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("groupId = ?", groupID).Delete(&models.FriendGroupMember{}).Error
+	})
+}
+
+func (r *friendGroupRepository) AddMember(groupID, friendID uint64) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(
+		&models.FriendGroupMember{GroupID: groupID, FriendID: friendID}).Error
+}
+
+func (r *friendGroupRepository) RemoveMember(groupID, friendID uint64) error {
+	return r.db.Where("groupId = ? and friendId = ?", groupID, friendID).Delete(&models.FriendGroupMember{}).Error
+}
+
+func (r *friendGroupRepository) GetMembers(groupIDs []uint64) ([]models.FriendGroupMember, error) {
+	var res []models.FriendGroupMember
+	if len(groupIDs) == 0 {
+		return res, nil
+	}
+	err := r.db.Where("groupId in ?", groupIDs).Find(&res).Error
+	return res, err
+}
+
+// SetNickname nickname为空时删除备注
+func (r *friendGroupRepository) SetNickname(userID, friendID uint64, nickname string) error {
+	if nickname == "" {
+		return r.db.Where("userId = ? and friendId = ?", userID, friendID).Delete(&models.FriendNickname{}).Error
+	}
+	return r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&models.FriendNickname{
+		UserID:   userID,
+		FriendID: friendID,
+		Nickname: nickname,
+	}).Error
+}
+
+func (r *friendGroupRepository) GetNicknames(userID uint64) (map[uint64]string, error) {
+	var rows []models.FriendNickname
+	if err := r.db.Where("userId = ?", userID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	res := make(map[uint64]string, len(rows))
+	for _, row := range rows {
+		res[row.FriendID] = row.Nickname
+	}
+	return res, nil
+}
@@ -1,10 +1,13 @@
 package repositories
 
 import (
+	"database/sql"
 	"errors"
 	"steam-backend/models"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type FriendRepository interface {
@@ -13,14 +16,28 @@ type FriendRepository interface {
 	IsFriends(user1ID, user2ID uint64) (bool, error)
 	GetFriendCount(userID uint64) (int64, error)
 	GetFriendList(userID uint64) ([]models.User, error)
+	GetGroupFriendList(userID, groupID uint64) ([]models.User, error)
 	CreateInvitation(invitation *models.Invitation) error
 	UpdateInvitationStatus(id uint64, status string) error
 	GetInvitationByReceiver(receiverID uint64, status string) ([]models.Invitation, error)
 	GetInvitationBySender(receiverID uint64, status string) ([]models.Invitation, error)
+	PageInvitationsByReceiver(receiverID uint64, status string, page, pageSize int) ([]models.Invitation, int64, error)
+	PageInvitationsBySender(senderID uint64, status string, page, pageSize int) ([]models.Invitation, int64, error)
+	CountMutualFriends(userID uint64, otherIDs []uint64) (map[uint64]int64, error)
+	GetMutualFriends(user1ID, user2ID uint64) ([]models.User, error)
+	FindFriendsOfFriends(userID uint64, limit int) ([]uint64, error)
+	FindSharedWishlistUsers(userID uint64, limit int) ([]uint64, error)
+	CountSharedWishlist(userID uint64, otherIDs []uint64) (map[uint64]int64, error)
 	FindInvitation(senderID, receiverID uint64) (*models.Invitation, error)
 	GetInvitationByID(id uint64) (*models.Invitation, error)
 	AcceptInvitation(invitationID, userID uint64) error
 	RefuseInvitation(invitationID, userID uint64) error
+	BlockUser(blockerID, blockedID uint64) error
+	DeleteBlock(blockerID, blockedID uint64) error
+	IsBlockedEitherWay(user1ID, user2ID uint64) (bool, error)
+	GetBlockedList(blockerID uint64) ([]models.User, error)
+	CancelInvitation(invitationID, userID uint64) error
+	ExpireInvitations(createdBefore time.Time) (int64, error)
 }
 
 type friendRepository struct {
@@ -41,14 +58,40 @@ func (r *friendRepository) CreateFriendship(userID1, userID2 uint64) error {
 	return r.db.Create(&models.Friend{UserId1: userID1, UserId2: userID2}).Error
 }
 
+// DeleteFriendship 同时清理双方为对方设置的分组成员关系和备注名
 func (r *friendRepository) DeleteFriendship(user1ID, user2ID uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return deleteFriendship(tx, user1ID, user2ID)
+	})
+}
+
+func deleteFriendship(tx *gorm.DB, user1ID, user2ID uint64) error {
 	if user1ID > user2ID {
 		user1ID, user2ID = user2ID, user1ID
 	}
-	return r.db.Where("userId1 = ? and userId2 = ?", user1ID, user2ID).Delete(&models.Friend{}).Error
+	err := tx.Where("userId1 = ? and userId2 = ?", user1ID, user2ID).Delete(&models.Friend{}).Error
+	if err != nil {
+		return err
+	}
+
+	for _, pair := range [][2]uint64{{user1ID, user2ID}, {user2ID, user1ID}} {
+		err := tx.Where("friendId = ? and groupId IN (SELECT id FROM friend_groups WHERE ownerId = ?)",
+			pair[1], pair[0]).Delete(&models.FriendGroupMember{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("userId = ? and friendId = ?", pair[0], pair[1]).Delete(&models.FriendNickname{}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (r *friendRepository) IsFriends(user1ID, user2ID uint64) (bool, error) {
+	if user1ID > user2ID {
+		user1ID, user2ID = user2ID, user1ID
+	}
 	var count int64
 	err := r.db.Model(&models.Friend{}).Where(
 		"userId1 = ? and userId2 = ?", user1ID, user2ID).Count(&count).Error
@@ -62,14 +105,91 @@ func (r *friendRepository) GetFriendCount(userID uint64) (int64, error) {
 	return count, err
 }
 
+// friendIDsOf 好友关系只存一条边(userId1 < userId2)，取某人的全部好友需要两个方向合并，
+// param为sql.Named参数名
+func friendIDsOf(param string) string {
+	return "SELECT userId2 FROM friends WHERE userId1 = @" + param +
+		" UNION SELECT userId1 FROM friends WHERE userId2 = @" + param
+}
+
+var friendIDsSQL = friendIDsOf("me")
+
+// excludedIDsSQL 推荐时需要排除的人：好友、任一方向有待处理邀请的人以及任一方向的屏蔽
+var excludedIDsSQL = friendIDsSQL + `
+	UNION SELECT receiverId FROM invitations WHERE senderId = @me AND status = 'pending'
+	UNION SELECT senderId FROM invitations WHERE receiverId = @me AND status = 'pending'
+	UNION SELECT blockedId FROM blocks WHERE blockerId = @me
+	UNION SELECT blockerId FROM blocks WHERE blockedId = @me`
+
 func (r *friendRepository) GetFriendList(userID uint64) ([]models.User, error) {
 	var res []models.User
-	err := r.db.Table("friends").Select("users.*").Joins(
-		"Join users On users.userId = friends.userId2").Where(
-		"(userId1 = ? or userId2 = ?) and userId != ?", userID, userID, userID).Find(&res).Error
+	err := r.db.Where("userId IN ("+friendIDsSQL+")", sql.Named("me", userID)).Find(&res).Error
+	return res, err
+}
+
+// GetGroupFriendList 只返回userID的分组groupID中仍是好友的人
+func (r *friendRepository) GetGroupFriendList(userID, groupID uint64) ([]models.User, error) {
+	var res []models.User
+	err := r.db.Where("userId IN ("+friendIDsSQL+") AND userId IN (SELECT friendId FROM friend_group_members "+
+		"WHERE groupId = @group)", sql.Named("me", userID), sql.Named("group", groupID)).Find(&res).Error
+	return res, err
+}
+
+func (r *friendRepository) GetMutualFriends(user1ID, user2ID uint64) ([]models.User, error) {
+	var res []models.User
+	err := r.db.Where("userId IN ("+friendIDsOf("me")+") AND userId IN ("+friendIDsOf("other")+")",
+		sql.Named("me", user1ID), sql.Named("other", user2ID)).Find(&res).Error
 	return res, err
 }
 
+// FindFriendsOfFriends 返回好友的好友中共同好友最多的limit个人，已排除好友和有待处理邀请的人
+func (r *friendRepository) FindFriendsOfFriends(userID uint64, limit int) ([]uint64, error) {
+	var res []uint64
+	err := r.db.Raw(`SELECT fid FROM
+		(SELECT userId2 AS fid FROM friends WHERE userId1 IN (`+friendIDsSQL+`)
+		 UNION ALL SELECT userId1 AS fid FROM friends WHERE userId2 IN (`+friendIDsSQL+`)) f
+		WHERE fid <> @me AND fid NOT IN (`+excludedIDsSQL+`)
+		GROUP BY fid ORDER BY COUNT(*) DESC LIMIT @limit`,
+		sql.Named("me", userID), sql.Named("limit", limit)).Scan(&res).Error
+	return res, err
+}
+
+// FindSharedWishlistUsers 返回与userID愿望单重合最多的limit个人，排除规则同上
+func (r *friendRepository) FindSharedWishlistUsers(userID uint64, limit int) ([]uint64, error) {
+	var res []uint64
+	err := r.db.Raw(`SELECT userId FROM wishlist_items
+		WHERE appId IN (SELECT appId FROM wishlist_items WHERE userId = @me)
+		AND userId <> @me AND userId NOT IN (`+excludedIDsSQL+`)
+		GROUP BY userId ORDER BY COUNT(*) DESC LIMIT @limit`,
+		sql.Named("me", userID), sql.Named("limit", limit)).Scan(&res).Error
+	return res, err
+}
+
+// CountSharedWishlist 一次查询出userID与otherIDs中每个人愿望单里相同游戏的数量
+func (r *friendRepository) CountSharedWishlist(userID uint64, otherIDs []uint64) (map[uint64]int64, error) {
+	res := make(map[uint64]int64)
+	if len(otherIDs) == 0 {
+		return res, nil
+	}
+
+	var rows []struct {
+		UserID uint64
+		Cnt    int64
+	}
+	err := r.db.Raw(`SELECT userId AS user_id, COUNT(*) AS cnt FROM wishlist_items
+		WHERE userId IN @others AND appId IN (SELECT appId FROM wishlist_items WHERE userId = @me)
+		GROUP BY userId`,
+		sql.Named("others", otherIDs), sql.Named("me", userID)).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		res[row.UserID] = row.Cnt
+	}
+	return res, nil
+}
+
 func (r *friendRepository) CreateInvitation(invitation *models.Invitation) error {
 	return r.db.Create(invitation).Error
 }
@@ -82,7 +202,7 @@ func (r *friendRepository) GetInvitationByReceiver(receiverID uint64, status str
 	Invitation, error) {
 	var res []models.Invitation
 
-	query := r.db.Where("reciverId = ?", receiverID)
+	query := r.db.Where("receiverId = ?", receiverID)
 	if status != "" {
 		query = query.Where("status = ?", status)
 	}
@@ -102,11 +222,72 @@ func (r *friendRepository) GetInvitationBySender(senderID uint64, status string)
 	return res, err
 }
 
+func (r *friendRepository) PageInvitationsByReceiver(receiverID uint64, status string, page, pageSize int) ([]models.
+	Invitation, int64, error) {
+	return r.pageInvitations(r.db.Where("receiverId = ?", receiverID), status, page, pageSize)
+}
+
+func (r *friendRepository) PageInvitationsBySender(senderID uint64, status string, page, pageSize int) ([]models.
+	Invitation, int64, error) {
+	return r.pageInvitations(r.db.Where("senderId = ?", senderID), status, page, pageSize)
+}
+
+func (r *friendRepository) pageInvitations(query *gorm.DB, status string, page, pageSize int) ([]models.
+	Invitation, int64, error) {
+	var res []models.Invitation
+	var total int64
+
+	query = query.Model(&models.Invitation{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("createdAt DESC").Limit(pageSize).Offset(offset).Find(&res).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return res, total, nil
+}
+
+// CountMutualFriends 一次查询出userID与otherIDs中每个人的共同好友数，没有共同好友的不在结果中
+func (r *friendRepository) CountMutualFriends(userID uint64, otherIDs []uint64) (map[uint64]int64, error) {
+	res := make(map[uint64]int64)
+	if len(otherIDs) == 0 {
+		return res, nil
+	}
+
+	var rows []struct {
+		UserID uint64
+		Cnt    int64
+	}
+	err := r.db.Raw(`SELECT o.uid AS user_id, COUNT(*) AS cnt FROM
+		(SELECT userId1 AS uid, userId2 AS fid FROM friends WHERE userId1 IN @others
+		 UNION ALL SELECT userId2 AS uid, userId1 AS fid FROM friends WHERE userId2 IN @others) o
+		JOIN
+		(SELECT userId2 AS fid FROM friends WHERE userId1 = @me
+		 UNION ALL SELECT userId1 AS fid FROM friends WHERE userId2 = @me) m
+		ON o.fid = m.fid GROUP BY o.uid`,
+		sql.Named("others", otherIDs), sql.Named("me", userID)).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		res[row.UserID] = row.Cnt
+	}
+	return res, nil
+}
+
 func (r *friendRepository) FindInvitation(senderID, receiverID uint64) (*models.Invitation, error) {
 	var res models.Invitation
 
-	query := r.db.Where("senderId = ? and receiverId = ?", senderID, receiverID)
-	err := query.Find(&res).Error
+	query := r.db.Where("senderId = ? and receiverId = ? and status = ?", senderID, receiverID,
+		models.InvitationPending)
+	err := query.First(&res).Error
 	if err != nil {
 		return nil, err
 	}
@@ -139,24 +320,42 @@ func (r *friendRepository) AcceptInvitation(invitationID, userID uint64) error {
 			return errors.New("no authorize")
 		}
 
-		if invitation.Status != "pending" {
+		if invitation.Status != models.InvitationPending {
 			return errors.New("invitaion was done")
 		}
 
-		if err := tx.Model(&invitation).Update("status", "accepted").Error; err != nil {
+		if err := tx.Model(&invitation).Update("status", models.InvitationAccepted).Error; err != nil {
+			return err
+		}
+
+		//双方互相发出邀请时，接受其中一条后撤回另一条，避免再次接受时重复建立好友关系
+		err := tx.Model(&models.Invitation{}).
+			Where("senderId = ? and receiverId = ? and status = ?", invitation.ReceiverID, invitation.SenderID,
+				models.InvitationPending).
+			Update("status", models.InvitationCanceled).Error
+		if err != nil {
 			return err
 		}
 
+		user1ID, user2ID := invitation.SenderID, invitation.ReceiverID
+		if user1ID > user2ID {
+			user1ID, user2ID = user2ID, user1ID
+		}
 		friendShip := models.Friend{
-			UserId1: invitation.SenderID,
-			UserId2: invitation.ReceiverID,
+			UserId1: user1ID,
+			UserId2: user2ID,
 		}
 
 		if err := tx.Create(&friendShip).Error; err != nil {
 			return err
 		}
 
-		return nil
+		//双方的好友动态里都会出现这条记录
+		activities := []models.Activity{
+			{ActorID: invitation.SenderID, Type: models.ActivityFriendAdded, TargetID: invitation.ReceiverID},
+			{ActorID: invitation.ReceiverID, Type: models.ActivityFriendAdded, TargetID: invitation.SenderID},
+		}
+		return tx.Create(&activities).Error
 	})
 }
 
@@ -172,9 +371,78 @@ func (r *friendRepository) RefuseInvitation(invitationID, userID uint64) error {
 		return errors.New("no authorize")
 	}
 
-	if invitation.Status != "pending" {
+	if invitation.Status != models.InvitationPending {
 		return errors.New("invitaion was done")
 	}
 
-	return r.db.Model(&invitation).Update("status", "refused").Error
+	return r.db.Model(&invitation).Update("status", models.InvitationRefused).Error
+}
+
+func (r *friendRepository) CancelInvitation(invitationID, userID uint64) error {
+	var invitation models.Invitation
+
+	err := r.db.First(&invitation, invitationID).Error
+	if err != nil {
+		return err
+	}
+
+	if invitation.SenderID != userID {
+		return errors.New("no authorize")
+	}
+
+	if invitation.Status != models.InvitationPending {
+		return errors.New("invitaion was done")
+	}
+
+	return r.db.Model(&invitation).Update("status", models.InvitationCanceled).Error
+}
+
+// ExpireInvitations 把创建时间早于createdBefore且仍未处理的邀请标记为过期，返回标记的条数
+func (r *friendRepository) ExpireInvitations(createdBefore time.Time) (int64, error) {
+	res := r.db.Model(&models.Invitation{}).Where("status = ? and createdAt < ?",
+		models.InvitationPending, createdBefore).Update("status", models.InvitationExpired)
+	return res.RowsAffected, res.Error
+}
+
+// BlockUser 在同一个事务中屏蔽、解除好友关系并撤回两人之间任一方向的待处理邀请
+func (r *friendRepository) BlockUser(blockerID, blockedID uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(
+			&models.Block{BlockerID: blockerID, BlockedID: blockedID}).Error
+		if err != nil {
+			return err
+		}
+		if err := deleteFriendship(tx, blockerID, blockedID); err != nil {
+			return err
+		}
+		return tx.Model(&models.Invitation{}).Where(
+			"status = ? and ((senderId = ? and receiverId = ?) or (senderId = ? and receiverId = ?))",
+			models.InvitationPending, blockerID, blockedID, blockedID, blockerID).Update(
+			"status", models.InvitationCanceled).Error
+	})
+}
+
+func (r *friendRepository) DeleteBlock(blockerID, blockedID uint64) error {
+	res := r.db.Where("blockerId = ? and blockedId = ?", blockerID, blockedID).Delete(&models.Block{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *friendRepository) IsBlockedEitherWay(user1ID, user2ID uint64) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Block{}).Where(
+		"(blockerId = ? and blockedId = ?) or (blockerId = ? and blockedId = ?)",
+		user1ID, user2ID, user2ID, user1ID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *friendRepository) GetBlockedList(blockerID uint64) ([]models.User, error) {
+	var res []models.User
+	err := r.db.Where("userId IN (SELECT blockedId FROM blocks WHERE blockerId = ?)", blockerID).Find(&res).Error
+	return res, err
 }
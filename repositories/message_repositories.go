@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"steam-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type MessageRepository interface {
+	CreateMessage(message *models.Message) error
+	GetConversation(user1ID, user2ID, beforeID uint64, limit int) ([]models.Message, error)
+	PageConversations(userID uint64, page, pageSize int) ([]models.Message, int64, error)
+	CountUnreadBySender(receiverID uint64, senderIDs []uint64) (map[uint64]int64, error)
+	CountUnread(receiverID uint64) (int64, error)
+	MarkRead(receiverID, senderID uint64) (uint64, error)
+}
+
+type messageRepository struct {
+	db *gorm.DB
+}
+
+func NewMessageRepository(db *gorm.DB) (MessageRepository, error) {
+	if db == nil {
+		return nil, errors.New("gorm.DB is nil")
+	}
+	return &messageRepository{db: db}, nil
+}
+
+func (r *messageRepository) CreateMessage(message *models.Message) error {
+	return r.db.Create(message).Error
+}
+
+// GetConversation 按id倒序返回两人之间id小于beforeID的消息，beforeID为0时从最新一条开始
+func (r *messageRepository) GetConversation(user1ID, user2ID, beforeID uint64, limit int) ([]models.Message, error) {
+	var res []models.Message
+	query := r.db.Where("(senderId = ? and receiverId = ?) or (senderId = ? and receiverId = ?)",
+		user1ID, user2ID, user2ID, user1ID)
+	if beforeID > 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+	err := query.Order("id DESC").Limit(limit).Find(&res).Error
+	return res, err
+}
+
+// 每个会话取最新一条消息的id，会话对象是消息中除自己以外的另一方
+const lastMessageIDsSQL = "SELECT MAX(id) FROM messages WHERE senderId = @me OR receiverId = @me " +
+	"GROUP BY CASE WHEN senderId = @me THEN receiverId ELSE senderId END"
+
+// PageConversations 返回每个会话的最后一条消息，按最近活跃排序
+func (r *messageRepository) PageConversations(userID uint64, page, pageSize int) ([]models.Message, int64, error) {
+	var total int64
+	err := r.db.Raw("SELECT COUNT(*) FROM ("+lastMessageIDsSQL+") AS conversations",
+		sql.Named("me", userID)).Scan(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var res []models.Message
+	err = r.db.Where("id IN ("+lastMessageIDsSQL+")", sql.Named("me", userID)).
+		Order("id DESC").Limit(pageSize).Offset((page - 1) * pageSize).Find(&res).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return res, total, nil
+}
+
+func (r *messageRepository) CountUnreadBySender(receiverID uint64, senderIDs []uint64) (map[uint64]int64, error) {
+	res := make(map[uint64]int64, len(senderIDs))
+	if len(senderIDs) == 0 {
+		return res, nil
+	}
+
+	var rows []struct {
+		SenderID uint64 `gorm:"column:senderId"`
+		Count    int64  `gorm:"column:count"`
+	}
+	err := r.db.Model(&models.Message{}).Select("senderId, COUNT(*) AS count").
+		Where("receiverId = ? and readAt IS NULL and senderId IN ?", receiverID, senderIDs).
+		Group("senderId").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		res[row.SenderID] = row.Count
+	}
+	return res, nil
+}
+
+func (r *messageRepository) CountUnread(receiverID uint64) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Message{}).Where("receiverId = ? and readAt IS NULL", receiverID).Count(&count).Error
+	return count, err
+}
+
+// MarkRead 把senderID发给receiverID的未读消息全部标为已读，返回最后一条被标记消息的id，没有未读时返回0
+func (r *messageRepository) MarkRead(receiverID, senderID uint64) (uint64, error) {
+	var lastID uint64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Message{}).Where("senderId = ? and receiverId = ? and readAt IS NULL",
+			senderID, receiverID).Select("COALESCE(MAX(id), 0)").Scan(&lastID).Error
+		if err != nil || lastID == 0 {
+			return err
+		}
+		return tx.Model(&models.Message{}).
+			Where("senderId = ? and receiverId = ? and readAt IS NULL and id <= ?", senderID, receiverID, lastID).
+			Update("readAt", time.Now()).Error
+	})
+	return lastID, err
+}
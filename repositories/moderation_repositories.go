@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"errors"
+	"steam-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ModerationRepository interface {
+	CreateReport(report *models.Report) error
+	FindReport(id uint64) (*models.Report, error)
+	HasOpenReport(reporterID uint64, targetType string, targetID uint64) (bool, error)
+	PageReports(filter models.ReportFilterDto, page, pageSize int) ([]models.Report, int64, error)
+	ResolveReports(reportID uint64, status, action string, moderatorID uint64, note string) ([]uint64, error)
+	ReopenReports(ids []uint64) error
+	CreateBan(ban *models.UserBan) error
+	FindActiveBan(userID uint64) (*models.UserBan, error)
+	LiftBans(userID uint64) (int64, error)
+	CreateWarning(warning *models.UserWarning) error
+}
+
+type moderationRepository struct {
+	db *gorm.DB
+}
+
+func NewModerationRepository(db *gorm.DB) (ModerationRepository, error) {
+	if db == nil {
+		return nil, errors.New("gorm.DB is nil")
+	}
+	return &moderationRepository{db: db}, nil
+}
+
+func (r *moderationRepository) CreateReport(report *models.Report) error {
+	return r.db.Create(report).Error
+}
+
+func (r *moderationRepository) FindReport(id uint64) (*models.Report, error) {
+	var res models.Report
+	if err := r.db.Where("id = ?", id).First(&res).Error; err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *moderationRepository) HasOpenReport(reporterID uint64, targetType string, targetID uint64) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Report{}).
+		Where("reporterId = ? and targetType = ? and targetId = ? and status = ?",
+			reporterID, targetType, targetID, models.ReportStatusOpen).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// PageReports 按举报时间先后排序，先处理最早的举报
+func (r *moderationRepository) PageReports(filter models.ReportFilterDto, page, pageSize int) ([]models.Report, int64, error) {
+	var res []models.Report
+	var total int64
+
+	query := r.db.Model(&models.Report{}).Where("status = ?", filter.Status)
+	if filter.TargetType != "" {
+		query = query.Where("targetType = ?", filter.TargetType)
+	}
+	if filter.Reason != "" {
+		query = query.Where("reason = ?", filter.Reason)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("id").Limit(pageSize).Offset((page - 1) * pageSize).Find(&res).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return res, total, nil
+}
+
+// ResolveReports reportID仍未处理时，将同一内容的所有未处理举报一并结案并返回这些举报的ID；
+// reportID已被其他版主处理时返回空，调用方据此跳过处理动作
+func (r *moderationRepository) ResolveReports(reportID uint64, status, action string, moderatorID uint64,
+	note string) ([]uint64, error) {
+	var ids []uint64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var report models.Report
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? and status = ?", reportID, models.ReportStatusOpen).First(&report).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&models.Report{}).
+			Where("targetType = ? and targetId = ? and status = ?", report.TargetType, report.TargetID,
+				models.ReportStatusOpen).Pluck("id", &ids).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Report{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"status":      status,
+			"action":      action,
+			"moderatorId": moderatorID,
+			"note":        note,
+			"resolvedAt":  time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// ReopenReports 处理动作失败时把ResolveReports结案的举报恢复为未处理
+func (r *moderationRepository) ReopenReports(ids []uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.Report{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"status":      models.ReportStatusOpen,
+		"action":      "",
+		"moderatorId": 0,
+		"note":        "",
+		"resolvedAt":  nil,
+	}).Error
+}
+
+func (r *moderationRepository) CreateBan(ban *models.UserBan) error {
+	return r.db.Create(ban).Error
+}
+
+// FindActiveBan 有多条生效的封禁时返回到期最晚的一条
+func (r *moderationRepository) FindActiveBan(userID uint64) (*models.UserBan, error) {
+	var res models.UserBan
+	err := r.db.Where("userId = ? and expiresAt > ? and liftedAt IS NULL", userID, time.Now()).
+		Order("expiresAt DESC").First(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *moderationRepository) LiftBans(userID uint64) (int64, error) {
+	res := r.db.Model(&models.UserBan{}).
+		Where("userId = ? and expiresAt > ? and liftedAt IS NULL", userID, time.Now()).
+		Update("liftedAt", time.Now())
+	return res.RowsAffected, res.Error
+}
+
+func (r *moderationRepository) CreateWarning(warning *models.UserWarning) error {
+	return r.db.Create(warning).Error
+}
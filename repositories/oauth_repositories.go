@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"errors"
+	"steam-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrOAuthCodeInvalid = errors.New("authorization code invalid or expired")
+
+type OAuthRepository interface {
+	CreateClient(client *models.OAuthClient) error
+	FindClient(clientID string) (*models.OAuthClient, error)
+	GetClientsByOwner(ownerID uint64) ([]models.OAuthClient, error)
+	DeleteClient(clientID string, ownerID uint64) error
+	CreateCode(code *models.OAuthAuthorizationCode) error
+	ConsumeCode(codeHash string) (*models.OAuthAuthorizationCode, error)
+	DeleteExpiredCodes(now time.Time) error
+}
+
+type oauthRepository struct {
+	db *gorm.DB
+}
+
+func NewOAuthRepository(db *gorm.DB) (OAuthRepository, error) {
+	if db == nil {
+		return nil, errors.New("db to oauthRepository is nil")
+	}
+	return &oauthRepository{db: db}, nil
+}
+
+func (r *oauthRepository) CreateClient(client *models.OAuthClient) error {
+	return r.db.Create(client).Error
+}
+
+func (r *oauthRepository) FindClient(clientID string) (*models.OAuthClient, error) {
+	var res models.OAuthClient
+	err := r.db.Where("clientId = ?", clientID).First(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *oauthRepository) GetClientsByOwner(ownerID uint64) ([]models.OAuthClient, error) {
+	var res []models.OAuthClient
+	err := r.db.Where("ownerId = ?", ownerID).Order("createdAt DESC").Find(&res).Error
+	return res, err
+}
+
+// DeleteClient 同时删除该应用未使用的授权码，已签发的token由AuthMiddleware按应用是否存在判断失效
+func (r *oauthRepository) DeleteClient(clientID string, ownerID uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("clientId = ? and ownerId = ?", clientID, ownerID).Delete(&models.OAuthClient{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("clientId = ?", clientID).Delete(&models.OAuthAuthorizationCode{}).Error
+	})
+}
+
+func (r *oauthRepository) CreateCode(code *models.OAuthAuthorizationCode) error {
+	return r.db.Create(code).Error
+}
+
+// ConsumeCode 用条件更新保证授权码只能被兑换一次
+func (r *oauthRepository) ConsumeCode(codeHash string) (*models.OAuthAuthorizationCode, error) {
+	var res models.OAuthAuthorizationCode
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		update := tx.Model(&models.OAuthAuthorizationCode{}).Where(
+			"codeHash = ? and used = ? and expiresAt > ?", codeHash, false, time.Now()).Update("used", true)
+		if update.Error != nil {
+			return update.Error
+		}
+		if update.RowsAffected == 0 {
+			return ErrOAuthCodeInvalid
+		}
+		return tx.Where("codeHash = ?", codeHash).First(&res).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *oauthRepository) DeleteExpiredCodes(now time.Time) error {
+	return r.db.Where("expiresAt <= ? or used = ?", now, true).Delete(&models.OAuthAuthorizationCode{}).Error
+}
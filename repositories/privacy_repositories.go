@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"errors"
+	"steam-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PrivacyRepository interface {
+	FindByUserID(userID uint64) (*models.PrivacySettings, error)
+	FindByUserIDs(userIDs []uint64) ([]models.PrivacySettings, error)
+	Save(settings *models.PrivacySettings) error
+}
+
+type privacyRepository struct {
+	db *gorm.DB
+}
+
+func NewPrivacyRepository(db *gorm.DB) (PrivacyRepository, error) {
+	if db == nil {
+		return nil, errors.New("gorm.DB is nil")
+	}
+	return &privacyRepository{db: db}, nil
+}
+
+func (r *privacyRepository) FindByUserID(userID uint64) (*models.PrivacySettings, error) {
+	var res models.PrivacySettings
+	if err := r.db.Where("userId = ?", userID).First(&res).Error; err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *privacyRepository) FindByUserIDs(userIDs []uint64) ([]models.PrivacySettings, error) {
+	var res []models.PrivacySettings
+	if len(userIDs) == 0 {
+		return res, nil
+	}
+	err := r.db.Where("userId IN ?", userIDs).Find(&res).Error
+	return res, err
+}
+
+func (r *privacyRepository) Save(settings *models.PrivacySettings) error {
+	return r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(settings).Error
+}
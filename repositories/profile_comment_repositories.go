@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"errors"
+	"steam-backend/models"
+
+	"gorm.io/gorm"
+)
+
+type ProfileCommentRepository interface {
+	CreateComment(comment *models.ProfileComment) error
+	FindComment(id uint64) (*models.ProfileComment, error)
+	DeleteComment(id uint64) error
+	PageComments(profileID uint64, page, pageSize int) ([]models.ProfileComment, int64, error)
+}
+
+type profileCommentRepository struct {
+	db *gorm.DB
+}
+
+func NewProfileCommentRepository(db *gorm.DB) (ProfileCommentRepository, error) {
+	if db == nil {
+		return nil, errors.New("gorm.DB is nil")
+	}
+	return &profileCommentRepository{db: db}, nil
+}
+
+func (r *profileCommentRepository) CreateComment(comment *models.ProfileComment) error {
+	return r.db.Create(comment).Error
+}
+
+func (r *profileCommentRepository) FindComment(id uint64) (*models.ProfileComment, error) {
+	var res models.ProfileComment
+	if err := r.db.Where("id = ?", id).First(&res).Error; err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *profileCommentRepository) DeleteComment(id uint64) error {
+	return r.db.Where("id = ?", id).Delete(&models.ProfileComment{}).Error
+}
+
+func (r *profileCommentRepository) PageComments(profileID uint64, page, pageSize int) ([]models.ProfileComment, int64, error) {
+	var res []models.ProfileComment
+	var total int64
+
+	query := r.db.Model(&models.ProfileComment{}).Where("profileId = ?", profileID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("id DESC").Limit(pageSize).Offset((page - 1) * pageSize).Find(&res).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return res, total, nil
+}
@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"errors"
+	"steam-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReviewRepository interface {
+	CreateReview(review *models.Review) error
+	UpdateReview(review *models.Review) error
+	DeleteReview(review *models.Review) error
+	FindReview(id uint64) (*models.Review, error)
+	FindUserReview(appID, userID uint64) (*models.Review, error)
+	PageReviews(appID uint64, sort string, page, pageSize int) ([]models.Review, int64, error)
+	AddHelpfulVote(reviewID, userID uint64) error
+	RemoveHelpfulVote(reviewID, userID uint64) error
+}
+
+type reviewRepository struct {
+	db *gorm.DB
+}
+
+func NewReviewRepository(db *gorm.DB) (ReviewRepository, error) {
+	if db == nil {
+		return nil, errors.New("gorm.DB is nil")
+	}
+	return &reviewRepository{db: db}, nil
+}
+
+// positiveRateExpr 只根据当前行的计数计算好评率，不需要扫描评测表
+var positiveRateExpr = gorm.Expr("CASE WHEN reviewCount = 0 THEN 0 ELSE ROUND(recommendedCount * 100 / reviewCount) END")
+
+// applyReviewDelta 在同一事务中调整游戏的评测计数并重新计算好评率
+func applyReviewDelta(tx *gorm.DB, appID uint64, reviewDelta, recommendedDelta int) error {
+	err := tx.Model(&models.App{}).Where("appId = ?", appID).Updates(map[string]interface{}{
+		"reviewCount":      gorm.Expr("reviewCount + ?", reviewDelta),
+		"recommendedCount": gorm.Expr("recommendedCount + ?", recommendedDelta),
+	}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&models.App{}).Where("appId = ?", appID).Update("positiveRate", positiveRateExpr).Error
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (r *reviewRepository) CreateReview(review *models.Review) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(review).Error; err != nil {
+			return err
+		}
+		return applyReviewDelta(tx, review.AppID, 1, boolToInt(review.Recommended))
+	})
+}
+
+// UpdateReview 在事务内加锁重新读取评测，用数据库中的推荐状态计算好评数的变化，避免并发修改时计数漂移
+func (r *reviewRepository) UpdateReview(review *models.Review) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var current models.Review
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", review.ID).First(&current).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(review).Select("recommended", "content").Updates(review).Error
+		if err != nil {
+			return err
+		}
+		if review.Recommended == current.Recommended {
+			return nil
+		}
+		return applyReviewDelta(tx, current.AppID, 0, boolToInt(review.Recommended)-boolToInt(current.Recommended))
+	})
+}
+
+// DeleteReview 评测已被并发删除时不再调整计数
+func (r *reviewRepository) DeleteReview(review *models.Review) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var current models.Review
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", review.ID).First(&current).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("reviewId = ?", current.ID).Delete(&models.ReviewHelpfulVote{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("id = ?", current.ID).Delete(&models.Review{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		return applyReviewDelta(tx, current.AppID, -1, -boolToInt(current.Recommended))
+	})
+}
+
+func (r *reviewRepository) FindReview(id uint64) (*models.Review, error) {
+	var res models.Review
+	if err := r.db.Where("id = ?", id).First(&res).Error; err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *reviewRepository) FindUserReview(appID, userID uint64) (*models.Review, error) {
+	var res models.Review
+	if err := r.db.Where("appId = ? and userId = ?", appID, userID).First(&res).Error; err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *reviewRepository) PageReviews(appID uint64, sort string, page, pageSize int) ([]models.Review, int64, error) {
+	var res []models.Review
+	var total int64
+
+	query := r.db.Model(&models.Review{}).Where("appId = ?", appID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if sort == models.ReviewSortHelpful {
+		query = query.Order("helpfulCount DESC")
+	}
+	err := query.Order("id DESC").Limit(pageSize).Offset((page - 1) * pageSize).Find(&res).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return res, total, nil
+}
+
+// AddHelpfulVote 重复投票不会重复计数
+func (r *reviewRepository) AddHelpfulVote(reviewID, userID uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ReviewHelpfulVote{
+			ReviewID: reviewID,
+			UserID:   userID,
+		})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		return tx.Model(&models.Review{}).Where("id = ?", reviewID).
+			Update("helpfulCount", gorm.Expr("helpfulCount + 1")).Error
+	})
+}
+
+func (r *reviewRepository) RemoveHelpfulVote(reviewID, userID uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("reviewId = ? and userId = ?", reviewID, userID).Delete(&models.ReviewHelpfulVote{})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		return tx.Model(&models.Review{}).Where("id = ?", reviewID).
+			Update("helpfulCount", gorm.Expr("helpfulCount - 1")).Error
+	})
+}
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"errors"
+	"steam-backend/models"
+
+	"gorm.io/gorm"
+)
+
+type RoleRepository interface {
+	ChangeRole(actorID, targetID uint64, action, role string) error
+	GetAuditLogs(targetID uint64, page, pageSize int) ([]models.RoleAuditLog, int64, error)
+	FindRole(userID uint64) (string, error)
+}
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) (RoleRepository, error) {
+	if db == nil {
+		return nil, errors.New("db to roleRepository is nil")
+	}
+	return &roleRepository{db: db}, nil
+}
+
+// ChangeRole 在同一事务中修改用户角色并写入审计日志
+func (r *roleRepository) ChangeRole(actorID, targetID uint64, action, role string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Where("userId = ?", targetID).First(&user).Error; err != nil {
+			return err
+		}
+
+		if user.Role == role {
+			return nil
+		}
+
+		if err := tx.Model(&user).Update("role", role).Error; err != nil {
+			return err
+		}
+
+		auditLog := models.RoleAuditLog{
+			ActorID:  actorID,
+			TargetID: targetID,
+			Action:   action,
+			OldRole:  user.Role,
+			NewRole:  role,
+		}
+		return tx.Create(&auditLog).Error
+	})
+}
+
+// GetAuditLogs targetID为0时返回所有用户的日志
+func (r *roleRepository) GetAuditLogs(targetID uint64, page, pageSize int) ([]models.RoleAuditLog, int64, error) {
+	var res []models.RoleAuditLog
+	var total int64
+
+	query := r.db.Model(&models.RoleAuditLog{})
+	if targetID != 0 {
+		query = query.Where("targetId = ?", targetID)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("createdAt DESC").Limit(pageSize).Offset(offset).Find(&res).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return res, total, nil
+}
+
+func (r *roleRepository) FindRole(userID uint64) (string, error) {
+	var user models.User
+	if err := r.db.Select("role").Where("userId = ?", userID).First(&user).Error; err != nil {
+		return "", err
+	}
+	return user.Role, nil
+}
@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"errors"
+	"steam-backend/models"
+
+	"gorm.io/gorm"
+)
+
+type SimilarityRepository interface {
+	CountWishlistsByApp() ([]models.AppWishlistCount, error)
+	FindCoOccurrences(minSupport int) ([]models.AppSimilarity, error)
+	ReplaceAll(rows []models.AppSimilarity) error
+	FindRelated(appID uint64, limit int) ([]models.AppSimilarity, error)
+}
+
+type similarityRepository struct {
+	db *gorm.DB
+}
+
+func NewSimilarityRepository(db *gorm.DB) (SimilarityRepository, error) {
+	if db == nil {
+		return nil, errors.New("gorm.DB is nil")
+	}
+	return &similarityRepository{db: db}, nil
+}
+
+func (r *similarityRepository) CountWishlistsByApp() ([]models.AppWishlistCount, error) {
+	var res []models.AppWishlistCount
+	err := r.db.Model(&models.WishlistItem{}).
+		Select("appId AS app_id, COUNT(*) AS count").
+		Group("appId").
+		Scan(&res).Error
+	return res, err
+}
+
+// FindCoOccurrences 统计每对游戏被同一用户加入愿望单的次数，低于minSupport的组合直接丢弃。
+// 每对游戏会以(a,b)和(b,a)各返回一次
+func (r *similarityRepository) FindCoOccurrences(minSupport int) ([]models.AppSimilarity, error) {
+	var res []models.AppSimilarity
+	err := r.db.Table("wishlist_items AS w1").
+		Joins("JOIN wishlist_items AS w2 ON w2.userId = w1.userId AND w2.appId <> w1.appId").
+		Select("w1.appId AS app_id, w2.appId AS related_app_id, COUNT(*) AS support").
+		Group("w1.appId, w2.appId").
+		Having("COUNT(*) >= ?", minSupport).
+		Scan(&res).Error
+	return res, err
+}
+
+// ReplaceAll 在同一事务中清空后重新写入，重建期间读到的仍是旧数据
+func (r *similarityRepository) ReplaceAll(rows []models.AppSimilarity) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.AppSimilarity{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(rows, 500).Error
+	})
+}
+
+func (r *similarityRepository) FindRelated(appID uint64, limit int) ([]models.AppSimilarity, error) {
+	var res []models.AppSimilarity
+	err := r.db.Where("appId = ?", appID).Order("score DESC").Limit(limit).Find(&res).Error
+	return res, err
+}
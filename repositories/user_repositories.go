@@ -1,8 +1,10 @@
 package repositories
 
 import (
+	"database/sql"
 	"errors"
 	"steam-backend/models"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -10,11 +12,18 @@ import (
 type UserRepository interface {
 	Create(user *models.User) error
 	FindByID(id uint64) (*models.User, error)
+	FindByIDs(ids []uint64) ([]models.User, error)
 	FindByEmail(email string) (*models.User, error)
 	FindByUsername(username string) (*models.User, error)
 	Update(user *models.User) error
+	UpdateColumns(user *models.User, columns ...string) error
 	Delete(id uint64) error
-	SearchUsers(keyword string, limit int) ([]models.User, error)
+	GetAccountRecords(id uint64) (*models.AccountRecordsDto, error)
+	SearchUsers(keyword string, limit int, viewerID uint64) ([]models.User, error)
+	ChangeUsername(user *models.User, newName string) error
+	GetUsernameHistory(userID uint64) ([]models.UsernameHistory, error)
+	ScheduleDeletion(userID uint64, at *time.Time) error
+	FindDueForDeletion(now time.Time, limit int) ([]uint64, error)
 }
 
 type userRepository struct {
@@ -36,18 +45,27 @@ func (r *userRepository) FindByID(id uint64) (*models.User, error) {
 	var res models.User
 
 	query := r.db.Where("userId = ?", id)
-	err := query.Find(&res).Error
+	err := query.First(&res).Error
 	if err != nil {
 		return nil, err
 	}
 	return &res, nil
 }
 
+func (r *userRepository) FindByIDs(ids []uint64) ([]models.User, error) {
+	var res []models.User
+	if len(ids) == 0 {
+		return res, nil
+	}
+	err := r.db.Where("userId in ?", ids).Find(&res).Error
+	return res, err
+}
+
 func (r *userRepository) FindByEmail(email string) (*models.User, error) {
 	var res models.User
 
 	query := r.db.Where("email = ?", email)
-	err := query.Find(&res).Error
+	err := query.First(&res).Error
 	if err != nil {
 		return nil, err
 	}
@@ -57,8 +75,9 @@ func (r *userRepository) FindByEmail(email string) (*models.User, error) {
 func (r *userRepository) FindByUsername(username string) (*models.User, error) {
 	var res models.User
 
+	//Find查不到时不返回错误，这里用First以便调用方通过gorm.ErrRecordNotFound判断
 	query := r.db.Where("userName = ?", username)
-	err := query.Find(&res).Error
+	err := query.First(&res).Error
 	if err != nil {
 		return nil, err
 	}
@@ -69,17 +88,203 @@ func (r *userRepository) Update(user *models.User) error {
 	return r.db.Save(user).Error
 }
 
+// UpdateColumns 只写入指定的列，避免覆盖并发修改的角色、用户名等字段
+func (r *userRepository) UpdateColumns(user *models.User, columns ...string) error {
+	return r.db.Model(&models.User{}).Where("userId = ?", user.UserID).Select(columns).Updates(user).Error
+}
+
+// Delete 删除用户及所有关联数据，新增与用户关联的表时需要在这里一并清理
 func (r *userRepository) Delete(id uint64) error {
-	return r.db.Delete(&models.User{}, id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		//先扣减该用户所在群组的成员数，再删除成员记录
+		err := tx.Model(&models.CommunityGroup{}).
+			Where("id IN (SELECT groupId FROM community_members WHERE userId = ?)", id).
+			Update("memberCount", gorm.Expr("memberCount - 1")).Error
+		if err != nil {
+			return err
+		}
+
+		//撤销该用户投出的有帮助票，并从游戏评测计数中扣除该用户的评测
+		err = tx.Model(&models.Review{}).
+			Where("id IN (SELECT reviewId FROM review_helpful_votes WHERE userId = ?)", id).
+			Update("helpfulCount", gorm.Expr("helpfulCount - 1")).Error
+		if err != nil {
+			return err
+		}
+		var reviews []models.Review
+		if err := tx.Where("userId = ?", id).Find(&reviews).Error; err != nil {
+			return err
+		}
+		for _, review := range reviews {
+			if err := applyReviewDelta(tx, review.AppID, -1, -boolToInt(review.Recommended)); err != nil {
+				return err
+			}
+		}
+
+		//取消关注时同步扣减鉴赏家的关注数
+		err = tx.Model(&models.Curator{}).
+			Where("id IN (SELECT curatorId FROM curator_followers WHERE userId = ?)", id).
+			Update("followerCount", gorm.Expr("followerCount - 1")).Error
+		if err != nil {
+			return err
+		}
+
+		//该用户提交的举报保留在审核队列中，只清空举报人
+		err = tx.Model(&models.Report{}).Where("reporterId = ?", id).Update("reporterId", nil).Error
+		if err != nil {
+			return err
+		}
+
+		cascades := []struct {
+			model interface{}
+			query string
+		}{
+			{&models.Friend{}, "userId1 = @id or userId2 = @id"},
+			{&models.Invitation{}, "senderId = @id or receiverId = @id"},
+			{&models.Block{}, "blockerId = @id or blockedId = @id"},
+			{&models.FriendGroupMember{}, "friendId = @id or groupId IN (SELECT id FROM friend_groups WHERE ownerId = @id)"},
+			{&models.FriendGroup{}, "ownerId = @id"},
+			{&models.FriendNickname{}, "userId = @id or friendId = @id"},
+			{&models.Message{}, "senderId = @id or receiverId = @id"},
+			{&models.CommunityInvitation{}, "senderId = @id or receiverId = @id or " +
+				"groupId IN (SELECT id FROM community_groups WHERE ownerId = @id)"},
+			{&models.CommunityMember{}, "userId = @id or groupId IN (SELECT id FROM community_groups WHERE ownerId = @id)"},
+			{&models.CommunityGroup{}, "ownerId = @id"},
+			{&models.WishlistItem{}, "userId = @id"},
+			{&models.Activity{}, "actorId = @id or (type = '" + models.ActivityFriendAdded + "' and targetId = @id)"},
+			{&models.UsernameHistory{}, "userId = @id"},
+			{&models.PrivacySettings{}, "userId = @id"},
+			{&models.ProfileComment{}, "profileId = @id or authorId = @id"},
+			{&models.ReviewHelpfulVote{}, "userId = @id or reviewId IN (SELECT id FROM reviews WHERE userId = @id)"},
+			{&models.Review{}, "userId = @id"},
+			{&models.CuratorFollower{}, "userId = @id or curatorId IN (SELECT id FROM curators WHERE ownerId = @id)"},
+			{&models.CuratorListItem{}, "listId IN (SELECT l.id FROM curator_lists l JOIN curators c ON c.id = l.curatorId " +
+				"WHERE c.ownerId = @id)"},
+			{&models.CuratorList{}, "curatorId IN (SELECT id FROM curators WHERE ownerId = @id)"},
+			{&models.Curator{}, "ownerId = @id"},
+			{&models.Report{}, "targetUserId = @id"},
+			{&models.UserBan{}, "userId = @id"},
+			{&models.UserWarning{}, "userId = @id"},
+			{&models.OAuthAuthorizationCode{}, "userId = @id"},
+			{&models.OAuthClient{}, "ownerId = @id"},
+			{&models.APIKey{}, "ownerId = @id"},
+		}
+		for _, c := range cascades {
+			if err := tx.Where(c.query, sql.Named("id", id)).Delete(c.model).Error; err != nil {
+				return err
+			}
+		}
+
+		//审计日志保留，只是不再能关联到具体用户
+		return tx.Where("userId = ?", id).Delete(&models.User{}).Error
+	})
 }
 
-func (r *userRepository) SearchUsers(keyword string, limit int) ([]models.User, error) {
+// GetAccountRecords 导出Delete中会被删除的数据，修改Delete的清理范围时需要同步这里
+func (r *userRepository) GetAccountRecords(id uint64) (*models.AccountRecordsDto, error) {
+	var res models.AccountRecordsDto
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var privacy []models.PrivacySettings
+		if err := tx.Where("userId = ?", id).Limit(1).Find(&privacy).Error; err != nil {
+			return err
+		}
+		if len(privacy) > 0 {
+			res.PrivacySettings = &privacy[0]
+		}
+
+		queries := []struct {
+			dest  interface{}
+			query string
+		}{
+			{&res.Messages, "senderId = @id or receiverId = @id"},
+			{&res.ProfileComments, "profileId = @id or authorId = @id"},
+			{&res.Reviews, "userId = @id"},
+			{&res.HelpfulVotes, "userId = @id"},
+			{&res.FriendGroups, "ownerId = @id"},
+			{&res.FriendGroupMembers, "groupId IN (SELECT id FROM friend_groups WHERE ownerId = @id)"},
+			{&res.FriendNicknames, "userId = @id"},
+			{&res.Communities, "ownerId = @id"},
+			{&res.CommunityMemberships, "userId = @id"},
+			{&res.CommunityInvitations, "senderId = @id or receiverId = @id"},
+			{&res.Activities, "actorId = @id"},
+			{&res.Curators, "ownerId = @id"},
+			{&res.CuratorLists, "curatorId IN (SELECT id FROM curators WHERE ownerId = @id)"},
+			{&res.CuratorListItems, "listId IN (SELECT l.id FROM curator_lists l JOIN curators c ON c.id = l.curatorId " +
+				"WHERE c.ownerId = @id)"},
+			{&res.FollowedCurators, "userId = @id"},
+			{&res.Bans, "userId = @id"},
+			{&res.Warnings, "userId = @id"},
+			{&res.OAuthClients, "ownerId = @id"},
+			{&res.APIKeys, "ownerId = @id"},
+		}
+		for _, q := range queries {
+			if err := tx.Where(q.query, sql.Named("id", id)).Find(q.dest).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *userRepository) ScheduleDeletion(userID uint64, at *time.Time) error {
+	return r.db.Model(&models.User{}).Where("userId = ?", userID).Update("deletionScheduledAt", at).Error
+}
+
+func (r *userRepository) FindDueForDeletion(now time.Time, limit int) ([]uint64, error) {
+	var res []uint64
+	err := r.db.Model(&models.User{}).Where("deletionScheduledAt <= ?", now).Limit(limit).Pluck(
+		"userId", &res).Error
+	return res, err
+}
+
+// SearchUsers viewerID不为0时不返回被viewer屏蔽的用户
+func (r *userRepository) SearchUsers(keyword string, limit int, viewerID uint64) ([]models.User, error) {
 	var res []models.User
 
 	query := r.db.Where("userName like ?", keyword+"%")
+	if viewerID != 0 {
+		query = query.Where("userId NOT IN (SELECT blockedId FROM blocks WHERE blockerId = ?)", viewerID)
+	}
 	if limit > 0 {
 		query = query.Limit(limit)
 	}
 	err := query.Find(&res).Error
 	return res, err
 }
+
+// ChangeUsername 修改用户名并记录历史，两步在同一事务中完成
+func (r *userRepository) ChangeUsername(user *models.User, newName string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		history := models.UsernameHistory{
+			UserID:  user.UserID,
+			OldName: user.UserName,
+			NewName: newName,
+		}
+		if err := tx.Create(&history).Error; err != nil {
+			return err
+		}
+
+		err := tx.Model(user).Updates(map[string]interface{}{
+			"userName":          newName,
+			"userNameChangedAt": now,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		user.UserName = newName
+		user.UserNameChangedAt = &now
+		return nil
+	})
+}
+
+func (r *userRepository) GetUsernameHistory(userID uint64) ([]models.UsernameHistory, error) {
+	var res []models.UsernameHistory
+	err := r.db.Where("userId = ?", userID).Order("createdAt DESC").Find(&res).Error
+	return res, err
+}
@@ -14,6 +14,7 @@ type WishlistRepository interface {
 	GetWishlist(userID uint64) ([]models.WishlistItem, error)
 	IsInWishList(userID, appID uint64) (bool, error)
 	UpdateItemOrder(userID uint64, sortItems []models.SortItem) error
+	FindUserIDsByApp(appID uint64) ([]uint64, error)
 }
 
 type wishlistRepository struct {
@@ -70,7 +71,7 @@ func (r *wishlistRepository) GetWishlist(userID uint64) ([]models.WishlistItem,
 func (r *wishlistRepository) IsInWishList(userID, appID uint64) (bool, error) {
 	var count int64
 
-	err := r.db.Where("userId = ? and appId = ?", userID, appID).Count(&count).Error
+	err := r.db.Model(&models.WishlistItem{}).Where("userId = ? and appId = ?", userID, appID).Count(&count).Error
 
 	if count > 0 {
 		return true, err
@@ -96,3 +97,9 @@ func (r *wishlistRepository) UpdateItemOrder(userID uint64, sortItems []models.S
 
 	return tx.Commit().Error
 }
+
+func (r *wishlistRepository) FindUserIDsByApp(appID uint64) ([]uint64, error) {
+	var res []uint64
+	err := r.db.Model(&models.WishlistItem{}).Where("appId = ?", appID).Pluck("userId", &res).Error
+	return res, err
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"steam-backend/config"
+	"steam-backend/models"
+	"steam-backend/repositories"
+	"time"
+)
+
+// 每次清理任务最多处理的账号数，剩下的留给下一轮
+const purgeBatchSize = 100
+
+type AccountService interface {
+	ExportData(userID uint64) (*models.AccountExportDto, error)
+	ScheduleDeletion(userID uint64, password string) (time.Time, error)
+	CancelDeletion(userID uint64) error
+	PurgeDeletedAccounts() error
+}
+
+type accountService struct {
+	userRepo     repositories.UserRepository
+	friendRepo   repositories.FriendRepository
+	wishlistServ WishlistService
+	avatarServ   AvatarService
+	gracePeriod  time.Duration
+}
+
+func NewAccountService(userRepo repositories.UserRepository, friendRepo repositories.FriendRepository,
+	wishlistServ WishlistService, avatarServ AvatarService, cfg config.Config) AccountService {
+	return &accountService{
+		userRepo:     userRepo,
+		friendRepo:   friendRepo,
+		wishlistServ: wishlistServ,
+		avatarServ:   avatarServ,
+		gracePeriod:  cfg.AccountDeletionGracePeriod,
+	}
+}
+
+func (s *accountService) ExportData(userID uint64) (*models.AccountExportDto, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.userRepo.GetUsernameHistory(userID)
+	if err != nil {
+		return nil, err
+	}
+	friends, err := s.friendRepo.GetFriendList(userID)
+	if err != nil {
+		return nil, err
+	}
+	sent, err := s.friendRepo.GetInvitationBySender(userID, "")
+	if err != nil {
+		return nil, err
+	}
+	received, err := s.friendRepo.GetInvitationByReceiver(userID, "")
+	if err != nil {
+		return nil, err
+	}
+	wishlist, err := s.wishlistServ.GetWishlist(userID)
+	if err != nil {
+		return nil, err
+	}
+	blocked, err := s.friendRepo.GetBlockedList(userID)
+	if err != nil {
+		return nil, err
+	}
+	records, err := s.userRepo.GetAccountRecords(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	friendDtos := make([]models.UserDto, len(friends))
+	for i := range friends {
+		friendDtos[i] = toUserDto(&friends[i])
+	}
+	blockedDtos := make([]models.UserDto, len(blocked))
+	for i := range blocked {
+		blockedDtos[i] = toUserDto(&blocked[i])
+	}
+
+	return &models.AccountExportDto{
+		ExportedAt: time.Now(),
+		Profile: models.AccountProfileDto{
+			UserID:    user.UserID,
+			Email:     user.Email,
+			UserName:  user.UserName,
+			NickName:  user.NickName,
+			Avatar:    user.Avatar,
+			Bio:       user.Bio,
+			Role:      user.Role,
+			CreatedAt: user.CreatedAt,
+		},
+		UsernameHistory:     history,
+		Friends:             friendDtos,
+		SentInvitations:     sent,
+		ReceivedInvitations: received,
+		Wishlist:            wishlist,
+		BlockedUsers:        blockedDtos,
+		AccountRecordsDto:   *records,
+	}, nil
+}
+
+// ScheduleDeletion 需要再次输入密码确认，宽限期内可以取消
+func (s *accountService) ScheduleDeletion(userID uint64, password string) (time.Time, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !CheckPassword(password, user.PassWord) {
+		return time.Time{}, errors.New("password is incorrect")
+	}
+	if user.DeletionScheduledAt != nil {
+		return *user.DeletionScheduledAt, nil
+	}
+
+	at := time.Now().Add(s.gracePeriod)
+	if err := s.userRepo.ScheduleDeletion(userID, &at); err != nil {
+		return time.Time{}, err
+	}
+	return at, nil
+}
+
+func (s *accountService) CancelDeletion(userID uint64) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.DeletionScheduledAt == nil {
+		return errors.New("account deletion not scheduled")
+	}
+	return s.userRepo.ScheduleDeletion(userID, nil)
+}
+
+// PurgeDeletedAccounts 由后台任务定期调用，删除宽限期已过的账号及其关联数据
+func (s *accountService) PurgeDeletedAccounts() error {
+	ids, err := s.userRepo.FindDueForDeletion(time.Now(), purgeBatchSize)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, id := range ids {
+		user, err := s.userRepo.FindByID(id)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := s.userRepo.Delete(id); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		log.Printf("account %d purged", id)
+		//账号已删除，头像文件删除失败只记录日志
+		if err := s.avatarServ.DeleteAvatar(userAvatarPrefix(id), user.Avatar); err != nil {
+			log.Printf("delete avatar of purged account %d failed: %v", id, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"log"
+	"steam-backend/models"
+	"steam-backend/repositories"
+)
+
+type ActivityService interface {
+	Record(actorID uint64, activityType string, targetID uint64)
+	GetFeed(userID, cursor uint64, limit int) (*models.ActivityPageDto, error)
+}
+
+type activityService struct {
+	activityRepo   repositories.ActivityRepository
+	userRepo       repositories.UserRepository
+	appRepo        repositories.AppRepository
+	privacyService PrivacyService
+}
+
+func NewActivityService(activityRepo repositories.ActivityRepository, userRepo repositories.UserRepository,
+	appRepo repositories.AppRepository, privacyService PrivacyService) ActivityService {
+	return &activityService{
+		activityRepo:   activityRepo,
+		userRepo:       userRepo,
+		appRepo:        appRepo,
+		privacyService: privacyService,
+	}
+}
+
+// Record 记录动态失败不影响原操作，只记录日志
+func (s *activityService) Record(actorID uint64, activityType string, targetID uint64) {
+	err := s.activityRepo.CreateActivity(&models.Activity{
+		ActorID:  actorID,
+		Type:     activityType,
+		TargetID: targetID,
+	})
+	if err != nil {
+		log.Printf("record activity %s of user %d failed: %v", activityType, actorID, err)
+	}
+}
+
+func (s *activityService) GetFeed(userID, cursor uint64, limit int) (*models.ActivityPageDto, error) {
+	activities, err := s.activityRepo.GetFeed(userID, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var actorIDs, userIDs, appIDs []uint64
+	for _, activity := range activities {
+		actorIDs = append(actorIDs, activity.ActorID)
+		userIDs = append(userIDs, activity.ActorID)
+		switch activity.Type {
+		case models.ActivityFriendAdded:
+			userIDs = append(userIDs, activity.TargetID)
+		case models.ActivityWishlistAdded:
+			appIDs = append(appIDs, activity.TargetID)
+		}
+	}
+	users, err := s.userRepo.FindByIDs(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	userMap := make(map[uint64]models.UserDto, len(users))
+	for i := range users {
+		userMap[users[i].UserID] = toUserDto(&users[i])
+	}
+	apps, err := s.appRepo.FindByIDs(appIDs)
+	if err != nil {
+		return nil, err
+	}
+	appMap := make(map[uint64]models.AppDto, len(apps))
+	for i := range apps {
+		appMap[apps[i].AppId] = toAppDto(&apps[i])
+	}
+
+	//愿望单、好友列表和个人资料不对当前用户公开时，对应的动态也不展示
+	wishlistVisible, err := s.privacyService.FilterViewable(userID, actorIDs, models.PrivacyFieldWishlist)
+	if err != nil {
+		return nil, err
+	}
+	//好友动态同时暴露了双方的好友关系，需要双方的好友列表都可见
+	friendListVisible, err := s.privacyService.FilterViewable(userID, userIDs, models.PrivacyFieldFriendList)
+	if err != nil {
+		return nil, err
+	}
+	profileVisible, err := s.privacyService.FilterViewable(userID, actorIDs, models.PrivacyFieldProfile)
+	if err != nil {
+		return nil, err
+	}
+
+	//关联的用户或游戏已被删除的动态直接跳过
+	res := &models.ActivityPageDto{Activities: make([]models.ActivityDto, 0, len(activities))}
+	for _, activity := range activities {
+		actor, ok := userMap[activity.ActorID]
+		if !ok {
+			continue
+		}
+		if activity.Type == models.ActivityWishlistAdded && !wishlistVisible[activity.ActorID] ||
+			activity.Type == models.ActivityFriendAdded &&
+				(!friendListVisible[activity.ActorID] || !friendListVisible[activity.TargetID]) ||
+			activity.Type == models.ActivityProfileUpdated && !profileVisible[activity.ActorID] {
+			continue
+		}
+		dto := models.ActivityDto{
+			ID:        activity.ID,
+			Actor:     actor,
+			Type:      activity.Type,
+			CreatedAt: activity.CreatedAt,
+		}
+		switch activity.Type {
+		case models.ActivityFriendAdded:
+			friend, ok := userMap[activity.TargetID]
+			if !ok {
+				continue
+			}
+			dto.Friend = &friend
+		case models.ActivityWishlistAdded:
+			app, ok := appMap[activity.TargetID]
+			if !ok {
+				continue
+			}
+			dto.App = &app
+		}
+		res.Activities = append(res.Activities, dto)
+	}
+	if len(activities) == limit {
+		res.NextCursor = activities[len(activities)-1].ID
+	}
+	return res, nil
+}
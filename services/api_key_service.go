@@ -0,0 +1,206 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"steam-backend/config"
+	"steam-backend/models"
+	"steam-backend/repositories"
+	"steam-backend/utils"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	apiKeyPrefix       = "sk_"
+	maxAPIKeysPerOwner = 10
+)
+
+var ErrAPIKeyInvalid = errors.New("api key invalid or revoked")
+
+type APIKeyService interface {
+	CreateKey(ownerID uint64, req *models.APIKeyRequestDto) (*models.APIKeyDto, error)
+	GetKeys(ownerID uint64) ([]models.APIKeyDto, error)
+	RevokeKey(id, ownerID uint64) error
+	SetRateLimit(id uint64, rateLimit int) error
+	Authenticate(rawKey string) (*models.APIKey, error)
+	Allow(key *models.APIKey) (bool, time.Duration)
+	RecordUsage(keyID uint64)
+	FlushUsage() error
+}
+
+type keyUsage struct {
+	count    int64
+	lastUsed time.Time
+}
+
+type apiKeyService struct {
+	apiKeyRepo   repositories.APIKeyRepository
+	limiter      *utils.RateLimiter
+	defaultLimit int
+	maxLimit     int
+
+	usageMu sync.Mutex
+	usage   map[uint64]*keyUsage
+}
+
+func NewAPIKeyService(repo repositories.APIKeyRepository, cfg config.Config) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo:   repo,
+		limiter:      utils.NewRateLimiter(time.Minute),
+		defaultLimit: cfg.APIKeyDefaultRateLimit,
+		maxLimit:     cfg.APIKeyMaxRateLimit,
+		usage:        make(map[uint64]*keyUsage),
+	}
+}
+
+func (s *apiKeyService) CreateKey(ownerID uint64, req *models.APIKeyRequestDto) (*models.APIKeyDto, error) {
+	for _, scope := range req.Scopes {
+		if !containsString(models.AllAPIScopes, scope) {
+			return nil, errors.New("unknown scope: " + scope)
+		}
+	}
+
+	count, err := s.apiKeyRepo.CountActiveByOwner(ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxAPIKeysPerOwner {
+		return nil, fmt.Errorf("at most %d active api keys", maxAPIKeysPerOwner)
+	}
+
+	secret, err := randomHex(20)
+	if err != nil {
+		return nil, err
+	}
+	rawKey := apiKeyPrefix + secret
+
+	key := &models.APIKey{
+		OwnerID:   ownerID,
+		Name:      req.Name,
+		Prefix:    rawKey[:len(apiKeyPrefix)+6],
+		KeyHash:   hashAPIKey(rawKey),
+		Scopes:    strings.Join(req.Scopes, " "),
+		RateLimit: s.defaultLimit, //提高限额需要管理员调用SetRateLimit
+	}
+	if err := s.apiKeyRepo.Create(key); err != nil {
+		return nil, err
+	}
+
+	dto := convertToAPIKeyDto(key)
+	dto.Key = rawKey
+	return &dto, nil
+}
+
+func (s *apiKeyService) GetKeys(ownerID uint64) ([]models.APIKeyDto, error) {
+	keys, err := s.apiKeyRepo.GetByOwner(ownerID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]models.APIKeyDto, len(keys))
+	for i := range keys {
+		res[i] = convertToAPIKeyDto(&keys[i])
+	}
+	return res, nil
+}
+
+func (s *apiKeyService) RevokeKey(id, ownerID uint64) error {
+	return s.apiKeyRepo.Revoke(id, ownerID)
+}
+
+// SetRateLimit 供管理员调整单个key的限额，不能超过配置的上限
+func (s *apiKeyService) SetRateLimit(id uint64, rateLimit int) error {
+	if rateLimit <= 0 || rateLimit > s.maxLimit {
+		return fmt.Errorf("rateLimit must be between 1 and %d", s.maxLimit)
+	}
+	return s.apiKeyRepo.UpdateRateLimit(id, rateLimit)
+}
+
+func (s *apiKeyService) Authenticate(rawKey string) (*models.APIKey, error) {
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return nil, ErrAPIKeyInvalid
+	}
+	key, err := s.apiKeyRepo.FindByHash(hashAPIKey(rawKey))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPIKeyInvalid
+		}
+		return nil, err
+	}
+	return key, nil
+}
+
+func (s *apiKeyService) Allow(key *models.APIKey) (bool, time.Duration) {
+	return s.limiter.Allow(strconv.FormatUint(key.ID, 10), key.RateLimit)
+}
+
+func (s *apiKeyService) RecordUsage(keyID uint64) {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+
+	u, ok := s.usage[keyID]
+	if !ok {
+		u = &keyUsage{}
+		s.usage[keyID] = u
+	}
+	u.count++
+	u.lastUsed = time.Now()
+}
+
+// FlushUsage 把内存中累计的调用次数写回数据库，写失败的计数会并回下一轮
+func (s *apiKeyService) FlushUsage() error {
+	s.usageMu.Lock()
+	pending := s.usage
+	s.usage = make(map[uint64]*keyUsage)
+	s.usageMu.Unlock()
+
+	var errs []error
+	for id, u := range pending {
+		if err := s.apiKeyRepo.AddUsage(id, u.count, u.lastUsed); err != nil {
+			errs = append(errs, err)
+			s.restoreUsage(id, u)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *apiKeyService) restoreUsage(id uint64, u *keyUsage) {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+
+	cur, ok := s.usage[id]
+	if !ok {
+		s.usage[id] = u
+		return
+	}
+	cur.count += u.count
+	if u.lastUsed.After(cur.lastUsed) {
+		cur.lastUsed = u.lastUsed
+	}
+}
+
+// API key本身是高熵随机串，用sha256即可，不需要bcrypt这类慢哈希
+func hashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func convertToAPIKeyDto(key *models.APIKey) models.APIKeyDto {
+	return models.APIKeyDto{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     strings.Fields(key.Scopes),
+		RateLimit:  key.RateLimit,
+		UsageCount: key.UsageCount,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
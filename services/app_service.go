@@ -6,26 +6,21 @@ import (
 )
 
 type AppService interface {
-	GetRecommendations(limit int) ([]models.AppDto, error)
 	GetSpecials(limit int) ([]models.AppDto, error)
 	GetSearchSuggestions(keyword string, limit int) ([]models.AppDto, error)
 	GetAppByID(id uint64) (*models.AppDto, error)
+	UpdateDiscount(id uint64, discount float64) error
 }
 
 type appService struct {
-	appRepo repositories.AppRepository
+	appRepo      repositories.AppRepository
+	wishlistRepo repositories.WishlistRepository
+	hub          *EventHub
 }
 
-func NewAPPService(appRepo repositories.AppRepository) AppService {
-	return &appService{appRepo: appRepo}
-}
-
-func (s *appService) GetRecommendations(limit int) ([]models.AppDto, error) {
-	res, err := s.appRepo.FindRecommendations(limit)
-	if err != nil {
-		return nil, err
-	}
-	return s.convertToAppDtos(res), nil
+func NewAPPService(appRepo repositories.AppRepository, wishlistRepo repositories.WishlistRepository,
+	hub *EventHub) AppService {
+	return &appService{appRepo: appRepo, wishlistRepo: wishlistRepo, hub: hub}
 }
 
 func (s *appService) GetSpecials(limit int) ([]models.AppDto, error) {
@@ -50,19 +45,48 @@ func (s *appService) GetAppByID(id uint64) (*models.AppDto, error) {
 		return nil, err
 	}
 
-	appDto := s.convertToAppDto(res)
+	appDto := toAppDto(res)
 	return &appDto, nil
 }
 
+// UpdateDiscount 折扣加大时通知所有把该游戏加入愿望单的用户
+func (s *appService) UpdateDiscount(id uint64, discount float64) error {
+	app, err := s.appRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.appRepo.UpdateDiscount(id, discount); err != nil {
+		return err
+	}
+	if discount <= app.Discount {
+		return nil
+	}
+
+	userIDs, err := s.wishlistRepo.FindUserIDsByApp(id)
+	if err != nil {
+		return err
+	}
+	event := models.WishlistDiscountEventDto{
+		AppID:    app.AppId,
+		Name:     app.Name,
+		Price:    app.Price,
+		Discount: discount,
+	}
+	for _, userID := range userIDs {
+		s.hub.Publish(userID, models.EventWishlistDiscounted, event)
+	}
+	return nil
+}
+
 func (s *appService) convertToAppDtos(apps []models.App) []models.AppDto {
 	res := make([]models.AppDto, len(apps))
 	for i, app := range apps {
-		res[i] = s.convertToAppDto(&app)
+		res[i] = toAppDto(&app)
 	}
 	return res
 }
 
-func (s *appService) convertToAppDto(app *models.App) models.AppDto {
+func toAppDto(app *models.App) models.AppDto {
 	return models.AppDto{
 		AppId:        app.AppId,
 		Name:         app.Name,
@@ -70,5 +94,6 @@ func (s *appService) convertToAppDto(app *models.App) models.AppDto {
 		Discount:     app.Discount,
 		ImageURL:     app.ImageURL,
 		PositiveRate: app.PositiveRate,
+		ReviewCount:  app.ReviewCount,
 	}
 }
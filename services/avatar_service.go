@@ -0,0 +1,171 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" //注册解码器，image.Decode才能识别这些格式
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"steam-backend/config"
+	"steam-backend/models"
+	"steam-backend/repositories"
+	"steam-backend/storage"
+	"steam-backend/utils"
+	"strings"
+)
+
+// AvatarSizes 生成的缩略图边长，最后一个作为用户的默认头像
+var AvatarSizes = []int{32, 64, 184}
+
+// 解码前先检查尺寸，防止小文件解压出超大图片耗尽内存
+const maxAvatarDimension = 4096
+
+var allowedAvatarTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+}
+
+type AvatarService interface {
+	UploadAvatar(userID uint64, r io.Reader) (map[int]string, error)
+	StoreAvatar(keyPrefix string, r io.Reader) (map[int]string, error)
+	DeleteAvatar(keyPrefix, url string) error
+}
+
+type avatarService struct {
+	userRepo        repositories.UserRepository
+	activityService ActivityService
+	blobStore       storage.BlobStore
+	maxBytes        int64
+}
+
+func NewAvatarService(userRepo repositories.UserRepository, activityService ActivityService,
+	blobStore storage.BlobStore, cfg config.Config) AvatarService {
+	return &avatarService{
+		userRepo:        userRepo,
+		activityService: activityService,
+		blobStore:       blobStore,
+		maxBytes:        cfg.AvatarMaxBytes,
+	}
+}
+
+// UploadAvatar 返回各尺寸缩略图的url，并把最大尺寸的url写入User.Avatar，
+// 保存成功后删除旧头像的文件
+func (s *avatarService) UploadAvatar(userID uint64, r io.Reader) (map[int]string, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPrefix := userAvatarPrefix(userID)
+	urls, err := s.StoreAvatar(keyPrefix, r)
+	if err != nil {
+		return nil, err
+	}
+
+	//解码和缩放耗时较长，只写入avatar列，避免覆盖期间并发修改的其他字段
+	oldAvatar := user.Avatar
+	user.Avatar = urls[AvatarSizes[len(AvatarSizes)-1]]
+	if err := s.userRepo.UpdateColumns(user, "avatar"); err != nil {
+		return nil, err
+	}
+	s.activityService.Record(userID, models.ActivityProfileUpdated, 0)
+	if err := s.DeleteAvatar(keyPrefix, oldAvatar); err != nil {
+		log.Printf("delete old avatar of user %d failed: %v", userID, err)
+	}
+	return urls, nil
+}
+
+func userAvatarPrefix(userID uint64) string {
+	return fmt.Sprintf("avatars/%d", userID)
+}
+
+func communityAvatarPrefix(groupID uint64) string {
+	return fmt.Sprintf("communities/%d", groupID)
+}
+
+// StoreAvatar 校验并生成各尺寸缩略图，保存在keyPrefix目录下，返回尺寸到url的映射
+func (s *avatarService) StoreAvatar(keyPrefix string, r io.Reader) (map[int]string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, s.maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxBytes {
+		return nil, fmt.Errorf("avatar must be smaller than %d bytes", s.maxBytes)
+	}
+
+	//按文件内容判断类型，不信任客户端给的Content-Type
+	if !allowedAvatarTypes[http.DetectContentType(data)] {
+		return nil, errors.New("avatar must be png, jpeg or gif")
+	}
+
+	imgConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("invalid image")
+	}
+	if imgConfig.Width > maxAvatarDimension || imgConfig.Height > maxAvatarDimension {
+		return nil, fmt.Errorf("avatar must be at most %dx%d", maxAvatarDimension, maxAvatarDimension)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("invalid image")
+	}
+
+	version, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make(map[int]string, len(AvatarSizes))
+	for _, size := range AvatarSizes {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, utils.SquareThumbnail(img, size)); err != nil {
+			return nil, err
+		}
+
+		key := fmt.Sprintf("%s/%s_%d.png", keyPrefix, version, size)
+		url, err := s.blobStore.Put(key, &buf, "image/png")
+		if err != nil {
+			return nil, err
+		}
+		urls[size] = url
+	}
+	return urls, nil
+}
+
+// DeleteAvatar 按url中的版本号删除StoreAvatar生成的所有尺寸，
+// url为空或不是keyPrefix下生成的文件(如外部链接)时直接忽略
+func (s *avatarService) DeleteAvatar(keyPrefix, url string) error {
+	idx := strings.LastIndex(url, "/"+keyPrefix+"/")
+	if idx < 0 {
+		return nil
+	}
+	version, _, ok := strings.Cut(url[idx+len(keyPrefix)+2:], "_")
+	if !ok || version == "" || strings.Contains(version, "/") {
+		return nil
+	}
+
+	var errs []error
+	for _, size := range AvatarSizes {
+		if err := s.blobStore.Delete(fmt.Sprintf("%s/%s_%d.png", keyPrefix, version, size)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
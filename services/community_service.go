@@ -0,0 +1,473 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"log"
+	"steam-backend/models"
+	"steam-backend/repositories"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrCommunityForbidden 当前用户在群组中的角色不足以执行该操作
+var ErrCommunityForbidden = errors.New("permission denied")
+
+// communityRoleRank 数值越大权限越高，非成员为0
+var communityRoleRank = map[string]int{
+	models.CommunityRoleMember:  1,
+	models.CommunityRoleOfficer: 2,
+	models.CommunityRoleOwner:   3,
+}
+
+type CommunityService interface {
+	CreateGroup(userID uint64, dto models.CreateCommunityRequestDto) (*models.CommunityDto, error)
+	GetGroup(viewerID, groupID uint64) (*models.CommunityDto, error)
+	UpdateGroup(userID, groupID uint64, dto models.UpdateCommunityRequestDto) error
+	UploadAvatar(userID, groupID uint64, r io.Reader) (string, error)
+	DeleteGroup(userID, groupID uint64) error
+	JoinGroup(userID, groupID uint64) error
+	LeaveGroup(userID, groupID uint64) error
+	InviteUser(userID, groupID, receiverID uint64) error
+	AcceptInvitation(invitationID, userID uint64) error
+	RefuseInvitation(invitationID, userID uint64) error
+	GetReceivedInvitations(userID uint64, page, pageSize int) (*models.PageDto, error)
+	SetMemberRole(userID, groupID, targetID uint64, role string) error
+	KickMember(userID, groupID, targetID uint64) error
+	GetMembers(groupID uint64, page, pageSize int) (*models.PageDto, error)
+	GetUserGroups(viewerID, userID uint64, page, pageSize int) (*models.PageDto, error)
+}
+
+type communityService struct {
+	communityRepo repositories.CommunityRepository
+	friendRepo    repositories.FriendRepository
+	userRepo      repositories.UserRepository
+	avatarService AvatarService
+	textFilter    TextFilterService
+}
+
+func NewCommunityService(communityRepo repositories.CommunityRepository, friendRepo repositories.FriendRepository,
+	userRepo repositories.UserRepository, avatarService AvatarService, textFilter TextFilterService) CommunityService {
+	return &communityService{
+		communityRepo: communityRepo,
+		friendRepo:    friendRepo,
+		userRepo:      userRepo,
+		avatarService: avatarService,
+		textFilter:    textFilter,
+	}
+}
+
+func (s *communityService) CreateGroup(userID uint64, dto models.CreateCommunityRequestDto) (*models.CommunityDto, error) {
+	name := strings.TrimSpace(dto.Name)
+	tag := strings.TrimSpace(dto.Tag)
+	if name == "" || tag == "" {
+		return nil, errors.New("name and tag can not be blank")
+	}
+	if err := s.textFilter.Validate(name); err != nil {
+		return nil, err
+	}
+	if err := s.textFilter.Validate(tag); err != nil {
+		return nil, err
+	}
+	description, _, err := s.textFilter.Check(TextFieldCommunity, strings.TrimSpace(dto.Description))
+	if err != nil {
+		return nil, err
+	}
+	taken, err := s.communityRepo.IsNameOrTagTaken(name, tag, 0)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, errors.New("name or tag already taken")
+	}
+
+	joinPolicy := dto.JoinPolicy
+	if joinPolicy == "" {
+		joinPolicy = models.CommunityJoinPublic
+	}
+	group := &models.CommunityGroup{
+		Name:        name,
+		Tag:         tag,
+		Description: description,
+		JoinPolicy:  joinPolicy,
+		OwnerID:     userID,
+	}
+	if err := s.communityRepo.CreateGroup(group); err != nil {
+		return nil, err
+	}
+
+	res := toCommunityDto(group, models.CommunityRoleOwner)
+	return &res, nil
+}
+
+// GetGroup viewerID为0表示未登录
+func (s *communityService) GetGroup(viewerID, groupID uint64) (*models.CommunityDto, error) {
+	group, err := s.communityRepo.FindGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+	role, err := s.roleOf(groupID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := toCommunityDto(group, role)
+	return &res, nil
+}
+
+func (s *communityService) UpdateGroup(userID, groupID uint64, dto models.UpdateCommunityRequestDto) error {
+	group, err := s.requireRole(groupID, userID, models.CommunityRoleOfficer)
+	if err != nil {
+		return err
+	}
+
+	if dto.Name != nil {
+		name := strings.TrimSpace(*dto.Name)
+		if name == "" {
+			return errors.New("name can not be blank")
+		}
+		if err := s.textFilter.Validate(name); err != nil {
+			return err
+		}
+		taken, err := s.communityRepo.IsNameOrTagTaken(name, "", groupID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return errors.New("name already taken")
+		}
+		group.Name = name
+	}
+	if dto.Description != nil {
+		description, _, err := s.textFilter.Check(TextFieldCommunity, strings.TrimSpace(*dto.Description))
+		if err != nil {
+			return err
+		}
+		group.Description = description
+	}
+	if dto.JoinPolicy != nil {
+		group.JoinPolicy = *dto.JoinPolicy
+	}
+	return s.communityRepo.UpdateGroup(group)
+}
+
+func (s *communityService) UploadAvatar(userID, groupID uint64, r io.Reader) (string, error) {
+	group, err := s.requireRole(groupID, userID, models.CommunityRoleOfficer)
+	if err != nil {
+		return "", err
+	}
+
+	urls, err := s.avatarService.StoreAvatar(communityAvatarPrefix(groupID), r)
+	if err != nil {
+		return "", err
+	}
+	old := group.Avatar
+	group.Avatar = urls[AvatarSizes[len(AvatarSizes)-1]]
+	if err := s.communityRepo.UpdateGroup(group); err != nil {
+		return "", err
+	}
+	//新头像已生效，旧文件删除失败只记录日志
+	if err := s.avatarService.DeleteAvatar(communityAvatarPrefix(groupID), old); err != nil {
+		log.Printf("delete old avatar of group %d failed: %v", groupID, err)
+	}
+	return group.Avatar, nil
+}
+
+func (s *communityService) DeleteGroup(userID, groupID uint64) error {
+	group, err := s.requireRole(groupID, userID, models.CommunityRoleOwner)
+	if err != nil {
+		return err
+	}
+	if err := s.communityRepo.DeleteGroup(groupID); err != nil {
+		return err
+	}
+	if err := s.avatarService.DeleteAvatar(communityAvatarPrefix(groupID), group.Avatar); err != nil {
+		log.Printf("delete avatar of group %d failed: %v", groupID, err)
+	}
+	return nil
+}
+
+// JoinGroup 只有公开群组可以直接加入，仅限邀请的群组需要先收到邀请
+func (s *communityService) JoinGroup(userID, groupID uint64) error {
+	group, err := s.communityRepo.FindGroup(groupID)
+	if err != nil {
+		return err
+	}
+	if group.JoinPolicy != models.CommunityJoinPublic {
+		return errors.New("group is invite only")
+	}
+	return s.communityRepo.AddMember(groupID, userID, models.CommunityRoleMember)
+}
+
+// LeaveGroup 群主不能退出，只能解散群组
+func (s *communityService) LeaveGroup(userID, groupID uint64) error {
+	member, err := s.communityRepo.FindMember(groupID, userID)
+	if err != nil {
+		return err
+	}
+	if member.Role == models.CommunityRoleOwner {
+		return errors.New("owner can not leave, delete the group instead")
+	}
+	return s.communityRepo.RemoveMember(groupID, userID)
+}
+
+// InviteUser 公开群组的任何成员都可以邀请，仅限邀请的群组需要管理员以上
+func (s *communityService) InviteUser(userID, groupID, receiverID uint64) error {
+	group, err := s.requireRole(groupID, userID, models.CommunityRoleMember)
+	if err != nil {
+		return err
+	}
+	if group.JoinPolicy == models.CommunityJoinInvite {
+		if _, err := s.requireRole(groupID, userID, models.CommunityRoleOfficer); err != nil {
+			return err
+		}
+	}
+	if _, err := s.userRepo.FindByID(receiverID); err != nil {
+		return err
+	}
+	blocked, err := s.friendRepo.IsBlockedEitherWay(userID, receiverID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return errors.New("can not invite this user")
+	}
+
+	role, err := s.roleOf(groupID, receiverID)
+	if err != nil {
+		return err
+	}
+	if role != "" {
+		return errors.New("already a member")
+	}
+	_, err = s.communityRepo.FindPendingInvitation(groupID, receiverID)
+	if err == nil {
+		return errors.New("already send invitation")
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	return s.communityRepo.CreateInvitation(&models.CommunityInvitation{
+		GroupID:    groupID,
+		SenderID:   userID,
+		ReceiverID: receiverID,
+	})
+}
+
+func (s *communityService) AcceptInvitation(invitationID, userID uint64) error {
+	return s.communityRepo.AcceptInvitation(invitationID, userID)
+}
+
+func (s *communityService) RefuseInvitation(invitationID, userID uint64) error {
+	return s.communityRepo.RefuseInvitation(invitationID, userID)
+}
+
+func (s *communityService) GetReceivedInvitations(userID uint64, page, pageSize int) (*models.PageDto, error) {
+	invitations, total, err := s.communityRepo.PageInvitationsByReceiver(userID, models.InvitationPending,
+		page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	groupIDs := make([]uint64, len(invitations))
+	senderIDs := make([]uint64, len(invitations))
+	for i, invitation := range invitations {
+		groupIDs[i] = invitation.GroupID
+		senderIDs[i] = invitation.SenderID
+	}
+	groups, err := s.communityRepo.FindGroupsByIDs(groupIDs)
+	if err != nil {
+		return nil, err
+	}
+	groupMap := make(map[uint64]*models.CommunityGroup, len(groups))
+	for i := range groups {
+		groupMap[groups[i].ID] = &groups[i]
+	}
+	users, err := s.userRepo.FindByIDs(senderIDs)
+	if err != nil {
+		return nil, err
+	}
+	userMap := make(map[uint64]*models.User, len(users))
+	for i := range users {
+		userMap[users[i].UserID] = &users[i]
+	}
+
+	res := make([]models.CommunityInvitationDto, 0, len(invitations))
+	for _, invitation := range invitations {
+		group, ok := groupMap[invitation.GroupID]
+		sender, ok2 := userMap[invitation.SenderID]
+		if !ok || !ok2 {
+			continue
+		}
+		res = append(res, models.CommunityInvitationDto{
+			ID:        invitation.ID,
+			Group:     toCommunityDto(group, ""),
+			Sender:    toUserDto(sender),
+			Status:    invitation.Status,
+			CreatedAt: invitation.CreatedAt,
+		})
+	}
+
+	return &models.PageDto{
+		Total:     total,
+		PageIndex: page,
+		PageSize:  pageSize,
+		Data:      res,
+	}, nil
+}
+
+// SetMemberRole 只有群主可以任免管理员
+func (s *communityService) SetMemberRole(userID, groupID, targetID uint64, role string) error {
+	if _, err := s.requireRole(groupID, userID, models.CommunityRoleOwner); err != nil {
+		return err
+	}
+	if userID == targetID {
+		return errors.New("can not change own role")
+	}
+	if _, err := s.communityRepo.FindMember(groupID, targetID); err != nil {
+		return err
+	}
+	return s.communityRepo.UpdateMemberRole(groupID, targetID, role)
+}
+
+// KickMember 只能移除角色比自己低的成员
+func (s *communityService) KickMember(userID, groupID, targetID uint64) error {
+	if _, err := s.requireRole(groupID, userID, models.CommunityRoleOfficer); err != nil {
+		return err
+	}
+	actor, err := s.communityRepo.FindMember(groupID, userID)
+	if err != nil {
+		return err
+	}
+	target, err := s.communityRepo.FindMember(groupID, targetID)
+	if err != nil {
+		return err
+	}
+	if communityRoleRank[target.Role] >= communityRoleRank[actor.Role] {
+		return ErrCommunityForbidden
+	}
+	return s.communityRepo.RemoveMember(groupID, targetID)
+}
+
+func (s *communityService) GetMembers(groupID uint64, page, pageSize int) (*models.PageDto, error) {
+	if _, err := s.communityRepo.FindGroup(groupID); err != nil {
+		return nil, err
+	}
+	members, total, err := s.communityRepo.PageMembers(groupID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]uint64, len(members))
+	for i, member := range members {
+		userIDs[i] = member.UserID
+	}
+	users, err := s.userRepo.FindByIDs(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	userMap := make(map[uint64]*models.User, len(users))
+	for i := range users {
+		userMap[users[i].UserID] = &users[i]
+	}
+
+	res := make([]models.CommunityMemberDto, 0, len(members))
+	for _, member := range members {
+		user, ok := userMap[member.UserID]
+		if !ok {
+			continue
+		}
+		res = append(res, models.CommunityMemberDto{
+			User:     toUserDto(user),
+			Role:     member.Role,
+			JoinedAt: member.JoinedAt,
+		})
+	}
+
+	return &models.PageDto{
+		Total:     total,
+		PageIndex: page,
+		PageSize:  pageSize,
+		Data:      res,
+	}, nil
+}
+
+// GetUserGroups MyRole为查看者自己在各群组中的角色
+func (s *communityService) GetUserGroups(viewerID, userID uint64, page, pageSize int) (*models.PageDto, error) {
+	groups, total, err := s.communityRepo.PageUserGroups(userID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := map[uint64]string{}
+	if viewerID != 0 {
+		groupIDs := make([]uint64, len(groups))
+		for i, group := range groups {
+			groupIDs[i] = group.ID
+		}
+		roles, err = s.communityRepo.GetRoles(viewerID, groupIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res := make([]models.CommunityDto, len(groups))
+	for i := range groups {
+		res[i] = toCommunityDto(&groups[i], roles[groups[i].ID])
+	}
+
+	return &models.PageDto{
+		Total:     total,
+		PageIndex: page,
+		PageSize:  pageSize,
+		Data:      res,
+	}, nil
+}
+
+// roleOf 未加入群组时返回空字符串
+func (s *communityService) roleOf(groupID, userID uint64) (string, error) {
+	if userID == 0 {
+		return "", nil
+	}
+	member, err := s.communityRepo.FindMember(groupID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// requireRole 群组不存在时返回gorm.ErrRecordNotFound，角色不足时返回ErrCommunityForbidden
+func (s *communityService) requireRole(groupID, userID uint64, minRole string) (*models.CommunityGroup, error) {
+	group, err := s.communityRepo.FindGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+	role, err := s.roleOf(groupID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if communityRoleRank[role] < communityRoleRank[minRole] {
+		return nil, ErrCommunityForbidden
+	}
+	return group, nil
+}
+
+func toCommunityDto(group *models.CommunityGroup, myRole string) models.CommunityDto {
+	return models.CommunityDto{
+		ID:          group.ID,
+		Name:        group.Name,
+		Tag:         group.Tag,
+		Avatar:      group.Avatar,
+		Description: group.Description,
+		JoinPolicy:  group.JoinPolicy,
+		OwnerID:     group.OwnerID,
+		MemberCount: group.MemberCount,
+		MyRole:      myRole,
+		CreatedAt:   group.CreatedAt,
+	}
+}
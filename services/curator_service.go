@@ -0,0 +1,439 @@
+package services
+
+import (
+	"errors"
+	"steam-backend/models"
+	"steam-backend/repositories"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrCuratorForbidden 只有鉴赏家的创建者可以修改鉴赏家信息和列表
+var ErrCuratorForbidden = errors.New("permission denied")
+
+type CuratorService interface {
+	CreateCurator(userID uint64, dto models.CreateCuratorRequestDto) (*models.CuratorDto, error)
+	GetCurator(viewerID, curatorID uint64) (*models.CuratorDto, error)
+	UpdateCurator(userID, curatorID uint64, dto models.UpdateCuratorRequestDto) error
+	Follow(userID, curatorID uint64) error
+	Unfollow(userID, curatorID uint64) error
+	GetFollowedCurators(userID uint64, page, pageSize int) (*models.PageDto, error)
+	CreateList(userID, curatorID uint64, dto models.CuratorListRequestDto) (*models.CuratorListDto, error)
+	GetLists(curatorID uint64) ([]models.CuratorListDto, error)
+	GetList(curatorID, listID uint64) (*models.CuratorListDto, error)
+	UpdateList(userID, curatorID, listID uint64, dto models.CuratorListRequestDto) error
+	DeleteList(userID, curatorID, listID uint64) error
+	SetListItems(userID, curatorID, listID uint64, items []models.CuratorListItemRequestDto) error
+	GetFollowedPicks(userID uint64, page, pageSize int) (*models.PageDto, error)
+}
+
+type curatorService struct {
+	curatorRepo repositories.CuratorRepository
+	appRepo     repositories.AppRepository
+	textFilter  TextFilterService
+}
+
+func NewCuratorService(curatorRepo repositories.CuratorRepository, appRepo repositories.AppRepository,
+	textFilter TextFilterService) CuratorService {
+	return &curatorService{
+		curatorRepo: curatorRepo,
+		appRepo:     appRepo,
+		textFilter:  textFilter,
+	}
+}
+
+// CreateCurator 每个用户只能创建一个鉴赏家
+func (s *curatorService) CreateCurator(userID uint64, dto models.CreateCuratorRequestDto) (*models.CuratorDto, error) {
+	_, err := s.curatorRepo.FindCuratorByOwner(userID)
+	if err == nil {
+		return nil, errors.New("already owns a curator")
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(dto.Name)
+	if name == "" {
+		return nil, errors.New("name can not be blank")
+	}
+	if err := s.textFilter.Validate(name); err != nil {
+		return nil, err
+	}
+	description, _, err := s.textFilter.Check(TextFieldCurator, strings.TrimSpace(dto.Description))
+	if err != nil {
+		return nil, err
+	}
+	taken, err := s.curatorRepo.IsNameTaken(name, 0)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, errors.New("name already taken")
+	}
+
+	curator := &models.Curator{
+		OwnerID:     userID,
+		Name:        name,
+		Description: description,
+	}
+	if err := s.curatorRepo.CreateCurator(curator); err != nil {
+		return nil, err
+	}
+	res := toCuratorDto(curator, false)
+	return &res, nil
+}
+
+// GetCurator viewerID为0表示未登录
+func (s *curatorService) GetCurator(viewerID, curatorID uint64) (*models.CuratorDto, error) {
+	curator, err := s.curatorRepo.FindCurator(curatorID)
+	if err != nil {
+		return nil, err
+	}
+	following := false
+	if viewerID != 0 {
+		if following, err = s.curatorRepo.IsFollowing(curatorID, viewerID); err != nil {
+			return nil, err
+		}
+	}
+	res := toCuratorDto(curator, following)
+	return &res, nil
+}
+
+func (s *curatorService) UpdateCurator(userID, curatorID uint64, dto models.UpdateCuratorRequestDto) error {
+	curator, err := s.ownedCurator(userID, curatorID)
+	if err != nil {
+		return err
+	}
+
+	if dto.Name != nil {
+		name := strings.TrimSpace(*dto.Name)
+		if name == "" {
+			return errors.New("name can not be blank")
+		}
+		if err := s.textFilter.Validate(name); err != nil {
+			return err
+		}
+		taken, err := s.curatorRepo.IsNameTaken(name, curatorID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return errors.New("name already taken")
+		}
+		curator.Name = name
+	}
+	if dto.Description != nil {
+		description, _, err := s.textFilter.Check(TextFieldCurator, strings.TrimSpace(*dto.Description))
+		if err != nil {
+			return err
+		}
+		curator.Description = description
+	}
+	return s.curatorRepo.UpdateCurator(curator)
+}
+
+func (s *curatorService) Follow(userID, curatorID uint64) error {
+	if _, err := s.curatorRepo.FindCurator(curatorID); err != nil {
+		return err
+	}
+	return s.curatorRepo.Follow(curatorID, userID)
+}
+
+func (s *curatorService) Unfollow(userID, curatorID uint64) error {
+	return s.curatorRepo.Unfollow(curatorID, userID)
+}
+
+func (s *curatorService) GetFollowedCurators(userID uint64, page, pageSize int) (*models.PageDto, error) {
+	curators, total, err := s.curatorRepo.PageFollowedCurators(userID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]models.CuratorDto, len(curators))
+	for i := range curators {
+		res[i] = toCuratorDto(&curators[i], true)
+	}
+	return &models.PageDto{
+		Total:     total,
+		PageIndex: page,
+		PageSize:  pageSize,
+		Data:      res,
+	}, nil
+}
+
+func (s *curatorService) CreateList(userID, curatorID uint64, dto models.CuratorListRequestDto) (*models.CuratorListDto, error) {
+	if _, err := s.ownedCurator(userID, curatorID); err != nil {
+		return nil, err
+	}
+	title, description, err := s.checkListText(dto)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &models.CuratorList{
+		CuratorID:   curatorID,
+		Title:       title,
+		Description: description,
+	}
+	if err := s.curatorRepo.CreateList(list); err != nil {
+		return nil, err
+	}
+	res := toCuratorListDto(list, nil)
+	return &res, nil
+}
+
+func (s *curatorService) GetLists(curatorID uint64) ([]models.CuratorListDto, error) {
+	if _, err := s.curatorRepo.FindCurator(curatorID); err != nil {
+		return nil, err
+	}
+	lists, err := s.curatorRepo.GetLists(curatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]models.CuratorListDto, len(lists))
+	for i := range lists {
+		res[i] = toCuratorListDto(&lists[i], nil)
+	}
+	return res, nil
+}
+
+// GetList 已从商店下架的游戏不再展示
+func (s *curatorService) GetList(curatorID, listID uint64) (*models.CuratorListDto, error) {
+	list, err := s.findList(curatorID, listID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.curatorRepo.GetItems(listID)
+	if err != nil {
+		return nil, err
+	}
+
+	appIDs := make([]uint64, len(items))
+	for i, item := range items {
+		appIDs[i] = item.AppID
+	}
+	apps, err := s.appRepo.FindByIDs(appIDs)
+	if err != nil {
+		return nil, err
+	}
+	appMap := make(map[uint64]models.AppDto, len(apps))
+	for i := range apps {
+		appMap[apps[i].AppId] = toAppDto(&apps[i])
+	}
+
+	itemDtos := make([]models.CuratorListItemDto, 0, len(items))
+	for _, item := range items {
+		app, ok := appMap[item.AppID]
+		if !ok {
+			continue
+		}
+		itemDtos = append(itemDtos, models.CuratorListItemDto{
+			App:     app,
+			Verdict: item.Verdict,
+			Blurb:   item.Blurb,
+		})
+	}
+	res := toCuratorListDto(list, itemDtos)
+	return &res, nil
+}
+
+func (s *curatorService) UpdateList(userID, curatorID, listID uint64, dto models.CuratorListRequestDto) error {
+	list, err := s.ownedList(userID, curatorID, listID)
+	if err != nil {
+		return err
+	}
+	title, description, err := s.checkListText(dto)
+	if err != nil {
+		return err
+	}
+	list.Title = title
+	list.Description = description
+	return s.curatorRepo.UpdateList(list)
+}
+
+func (s *curatorService) checkListText(dto models.CuratorListRequestDto) (string, string, error) {
+	title := strings.TrimSpace(dto.Title)
+	if title == "" {
+		return "", "", errors.New("title can not be blank")
+	}
+	title, _, err := s.textFilter.Check(TextFieldCurator, title)
+	if err != nil {
+		return "", "", err
+	}
+	description, _, err := s.textFilter.Check(TextFieldCurator, strings.TrimSpace(dto.Description))
+	if err != nil {
+		return "", "", err
+	}
+	return title, description, nil
+}
+
+func (s *curatorService) DeleteList(userID, curatorID, listID uint64) error {
+	if _, err := s.ownedList(userID, curatorID, listID); err != nil {
+		return err
+	}
+	return s.curatorRepo.DeleteList(listID)
+}
+
+// SetListItems 整体替换列表内容，items的顺序即展示顺序
+func (s *curatorService) SetListItems(userID, curatorID, listID uint64, items []models.CuratorListItemRequestDto) error {
+	if _, err := s.ownedList(userID, curatorID, listID); err != nil {
+		return err
+	}
+
+	appIDs := make([]uint64, len(items))
+	seen := make(map[uint64]bool, len(items))
+	for i, item := range items {
+		if seen[item.AppID] {
+			return errors.New("duplicate app in list")
+		}
+		seen[item.AppID] = true
+		appIDs[i] = item.AppID
+	}
+	apps, err := s.appRepo.FindByIDs(appIDs)
+	if err != nil {
+		return err
+	}
+	if len(apps) != len(appIDs) {
+		return errors.New("app not exists")
+	}
+
+	rows := make([]models.CuratorListItem, len(items))
+	for i, item := range items {
+		blurb, _, err := s.textFilter.Check(TextFieldCurator, strings.TrimSpace(item.Blurb))
+		if err != nil {
+			return err
+		}
+		rows[i] = models.CuratorListItem{
+			ListID:   listID,
+			AppID:    item.AppID,
+			Position: i,
+			Verdict:  item.Verdict,
+			Blurb:    blurb,
+		}
+	}
+	return s.curatorRepo.ReplaceItems(listID, rows)
+}
+
+// GetFollowedPicks 关注的鉴赏家推荐的游戏，被越多鉴赏家推荐的越靠前
+func (s *curatorService) GetFollowedPicks(userID uint64, page, pageSize int) (*models.PageDto, error) {
+	appIDs, total, err := s.curatorRepo.PageFollowedPicks(userID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	apps, err := s.appRepo.FindByIDs(appIDs)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.curatorRepo.GetFollowedPickRows(userID, appIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	curatorIDs := make([]uint64, 0, len(rows))
+	for _, row := range rows {
+		curatorIDs = append(curatorIDs, row.CuratorID)
+	}
+	curators, err := s.curatorRepo.FindCuratorsByIDs(curatorIDs)
+	if err != nil {
+		return nil, err
+	}
+	curatorNames := make(map[uint64]string, len(curators))
+	for _, curator := range curators {
+		curatorNames[curator.ID] = curator.Name
+	}
+
+	//同一鉴赏家在多个列表中推荐同一游戏时只保留最近更新的列表中的短评
+	reasons := make(map[uint64][]models.CuratorPickReasonDto, len(appIDs))
+	seen := make(map[[2]uint64]bool, len(rows))
+	for _, row := range rows {
+		key := [2]uint64{row.AppID, row.CuratorID}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		reasons[row.AppID] = append(reasons[row.AppID], models.CuratorPickReasonDto{
+			CuratorID:   row.CuratorID,
+			CuratorName: curatorNames[row.CuratorID],
+			Blurb:       row.Blurb,
+		})
+	}
+
+	appMap := make(map[uint64]*models.App, len(apps))
+	for i := range apps {
+		appMap[apps[i].AppId] = &apps[i]
+	}
+	res := make([]models.CuratorPickDto, 0, len(appIDs))
+	for _, appID := range appIDs {
+		app, ok := appMap[appID]
+		if !ok {
+			continue
+		}
+		res = append(res, models.CuratorPickDto{
+			App:      toAppDto(app),
+			Curators: reasons[appID],
+		})
+	}
+
+	return &models.PageDto{
+		Total:     total,
+		PageIndex: page,
+		PageSize:  pageSize,
+		Data:      res,
+	}, nil
+}
+
+func (s *curatorService) ownedCurator(userID, curatorID uint64) (*models.Curator, error) {
+	curator, err := s.curatorRepo.FindCurator(curatorID)
+	if err != nil {
+		return nil, err
+	}
+	if curator.OwnerID != userID {
+		return nil, ErrCuratorForbidden
+	}
+	return curator, nil
+}
+
+// findList 列表不属于该鉴赏家时按不存在处理
+func (s *curatorService) findList(curatorID, listID uint64) (*models.CuratorList, error) {
+	list, err := s.curatorRepo.FindList(listID)
+	if err != nil {
+		return nil, err
+	}
+	if list.CuratorID != curatorID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return list, nil
+}
+
+func (s *curatorService) ownedList(userID, curatorID, listID uint64) (*models.CuratorList, error) {
+	if _, err := s.ownedCurator(userID, curatorID); err != nil {
+		return nil, err
+	}
+	return s.findList(curatorID, listID)
+}
+
+func toCuratorDto(curator *models.Curator, following bool) models.CuratorDto {
+	return models.CuratorDto{
+		ID:            curator.ID,
+		OwnerID:       curator.OwnerID,
+		Name:          curator.Name,
+		Description:   curator.Description,
+		FollowerCount: curator.FollowerCount,
+		Following:     following,
+		CreatedAt:     curator.CreatedAt,
+	}
+}
+
+func toCuratorListDto(list *models.CuratorList, items []models.CuratorListItemDto) models.CuratorListDto {
+	return models.CuratorListDto{
+		ID:          list.ID,
+		CuratorID:   list.CuratorID,
+		Title:       list.Title,
+		Description: list.Description,
+		Items:       items,
+		CreatedAt:   list.CreatedAt,
+		UpdatedAt:   list.UpdatedAt,
+	}
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"steam-backend/models"
+	"sync"
+	"time"
+)
+
+// Subscription 一个连接对应一个订阅，Events被关闭说明连接太慢被踢掉或已取消订阅
+type Subscription struct {
+	userID uint64
+	events chan models.Event
+}
+
+func (s *Subscription) Events() <-chan models.Event {
+	return s.events
+}
+
+// EventHub 进程内的发布订阅中心，按用户分发事件，并为每个用户保留最近的事件用于断线重连补发
+type EventHub struct {
+	mu          sync.Mutex
+	nextID      uint64
+	subscribers map[uint64]map[*Subscription]struct{}
+	history     map[uint64][]models.Event
+	//trimmed为每个用户因超出数量被丢弃的最新事件ID，purged为因超时被清理的最新事件ID
+	trimmed     map[uint64]uint64
+	purged      uint64
+	bufferSize  int
+	historySize int
+	replayTTL   time.Duration
+}
+
+// eventIDsPerMilli 事件ID从启动时间起算，保证重启后ID不会变小；
+// 每毫秒预留1000个ID，ID仍小于2^53，浏览器按double解析也不会丢精度
+const eventIDsPerMilli = 1000
+
+func NewEventHub(bufferSize, historySize int, replayTTL time.Duration) *EventHub {
+	startID := uint64(time.Now().UnixMilli()) * eventIDsPerMilli
+	return &EventHub{
+		nextID:      startID,
+		purged:      startID, //重启前的事件都无法补发
+		subscribers: make(map[uint64]map[*Subscription]struct{}),
+		history:     make(map[uint64][]models.Event),
+		trimmed:     make(map[uint64]uint64),
+		bufferSize:  bufferSize,
+		historySize: historySize,
+		replayTTL:   replayTTL,
+	}
+}
+
+// Publish 不会阻塞发布者：订阅者缓冲区满时直接断开该订阅，由客户端重连后补发
+func (h *EventHub) Publish(userID uint64, eventType string, data any) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	event := models.Event{
+		ID:        h.nextID,
+		Type:      eventType,
+		Data:      data,
+		CreatedAt: time.Now(),
+	}
+
+	history := append(h.history[userID], event)
+	if len(history) > h.historySize {
+		h.trimmed[userID] = history[len(history)-h.historySize-1].ID
+		history = history[len(history)-h.historySize:]
+	}
+	h.history[userID] = history
+
+	for sub := range h.subscribers[userID] {
+		select {
+		case sub.events <- event:
+		default:
+			h.remove(sub)
+		}
+	}
+}
+
+// Subscribe lastEventID大于0时返回之后错过的事件；错过的事件可能已超出保留范围时
+// 只返回一个reset事件，其ID为当前最新的事件ID，客户端收到后重新拉取完整数据
+func (h *EventHub) Subscribe(userID, lastEventID uint64) (*Subscription, []models.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub := &Subscription{userID: userID, events: make(chan models.Event, h.bufferSize)}
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[*Subscription]struct{})
+	}
+	h.subscribers[userID][sub] = struct{}{}
+
+	//lastEventID大于当前ID说明是其他实例或时钟回拨前签发的，同样无法补发
+	if lastEventID > 0 && (lastEventID < h.trimmed[userID] || lastEventID < h.purged || lastEventID > h.nextID) {
+		return sub, []models.Event{{
+			ID:        h.nextID,
+			Type:      models.EventReset,
+			CreatedAt: time.Now(),
+		}}
+	}
+
+	var missed []models.Event
+	if lastEventID > 0 {
+		for _, event := range h.history[userID] {
+			if event.ID > lastEventID {
+				missed = append(missed, event)
+			}
+		}
+	}
+	return sub, missed
+}
+
+func (h *EventHub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(sub)
+}
+
+func (h *EventHub) remove(sub *Subscription) {
+	subs, ok := h.subscribers[sub.userID]
+	if !ok {
+		return
+	}
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	close(sub.events)
+	if len(subs) == 0 {
+		delete(h.subscribers, sub.userID)
+	}
+}
+
+// PurgeHistory 由后台任务定期调用，清理超过补发时间窗口的事件
+func (h *EventHub) PurgeHistory() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cutoff := time.Now().Add(-h.replayTTL)
+	for userID, history := range h.history {
+		i := 0
+		for i < len(history) && history[i].CreatedAt.Before(cutoff) {
+			h.purged = max(h.purged, history[i].ID)
+			i++
+		}
+		if i == len(history) {
+			delete(h.history, userID)
+		} else if i > 0 {
+			h.history[userID] = append([]models.Event(nil), history[i:]...)
+		}
+	}
+	//已被purged覆盖的记录不再需要单独保存
+	for userID, id := range h.trimmed {
+		if id <= h.purged {
+			delete(h.trimmed, userID)
+		}
+	}
+	return nil
+}
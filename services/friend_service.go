@@ -2,36 +2,93 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"steam-backend/config"
 	"steam-backend/models"
 	"steam-backend/repositories"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type FriendService interface {
-	SendInvitation(senderID, receiverID uint64) error
+	SendInvitation(senderID, receiverID uint64, message string) error
 	AcceptInvitation(invitationID, userID uint64) error
 	RefuseInvitation(invitationID, userID uint64) error
-	GetReceivedInvitations(userID uint64) ([]models.Invitation, error)
-	GetSentInvitations(userID uint64) ([]models.Invitation, error)
+	CancelInvitation(invitationID, userID uint64) error
+	ExpireInvitations() error
+	GetReceivedInvitations(userID uint64, page, pageSize int) (*models.PageDto, error)
+	GetSentInvitations(userID uint64, page, pageSize int) (*models.PageDto, error)
 	RemoveFriend(userID, friendID uint64) error
 	CheckFriendShip(userID, friendID uint64) (bool, error)
-	GetFriendCount(userID int64) (int64, error)
-	GetFriendList(userID int64) ([]models.User, error)
+	GetFriendCount(userID uint64) (int64, error)
+	GetFriendList(userID, groupID uint64) ([]models.FriendDto, error)
+	GetUserFriendList(viewerID, userID uint64) ([]models.UserDto, error)
+	CreateGroup(userID uint64, name string) (*models.FriendGroupDto, error)
+	GetGroups(userID uint64) ([]models.FriendGroupDto, error)
+	RenameGroup(userID, groupID uint64, name string) error
+	DeleteGroup(userID, groupID uint64) error
+	AddGroupMember(userID, groupID, friendID uint64) error
+	RemoveGroupMember(userID, groupID, friendID uint64) error
+	SetNickname(userID, friendID uint64, nickname string) error
+	GetMutualFriends(userID, otherID uint64) ([]models.UserDto, error)
+	GetSuggestions(userID uint64, limit int) ([]models.FriendSuggestionDto, error)
+	BlockUser(userID, targetID uint64) error
+	UnblockUser(userID, targetID uint64) error
+	GetBlockedList(userID uint64) ([]models.UserDto, error)
 }
 
+// 推荐打分时共同好友的权重高于愿望单重合
+const (
+	suggestionMutualWeight   = 3
+	suggestionWishlistWeight = 1
+	suggestionPoolSize       = 100
+	maxFriendGroups          = 50
+)
+
 type friendService struct {
-	friendRepo repositories.FriendRepository
+	friendRepo       repositories.FriendRepository
+	groupRepo        repositories.FriendGroupRepository
+	userRepo         repositories.UserRepository
+	presenceService  PresenceService
+	privacyService   PrivacyService
+	hub              *EventHub
+	textFilter       TextFilterService
+	invitationExpiry time.Duration
 }
 
-func NewFriendService(repo repositories.FriendRepository) FriendService {
-	return &friendService{friendRepo: repo}
+func NewFriendService(repo repositories.FriendRepository, groupRepo repositories.FriendGroupRepository,
+	userRepo repositories.UserRepository, presenceService PresenceService, privacyService PrivacyService,
+	hub *EventHub, textFilter TextFilterService, cfg config.Config) FriendService {
+	return &friendService{
+		friendRepo:       repo,
+		groupRepo:        groupRepo,
+		userRepo:         userRepo,
+		presenceService:  presenceService,
+		privacyService:   privacyService,
+		hub:              hub,
+		textFilter:       textFilter,
+		invitationExpiry: cfg.InvitationExpiry,
+	}
 }
 
-func (s *friendService) SendInvitation(senderID, receiverID uint64) error {
+func (s *friendService) SendInvitation(senderID, receiverID uint64, message string) error {
 	if senderID == receiverID {
 		return errors.New("can not invited self")
 	}
+	if _, err := s.userRepo.FindByID(receiverID); err != nil {
+		return err
+	}
+	blocked, err := s.friendRepo.IsBlockedEitherWay(senderID, receiverID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return errors.New("can not invite this user")
+	}
 	isFriend, err := s.friendRepo.IsFriends(senderID, receiverID)
 	if err != nil {
 		return err
@@ -39,6 +96,7 @@ func (s *friendService) SendInvitation(senderID, receiverID uint64) error {
 	if isFriend {
 		return errors.New("already was friend")
 	}
+	//只有待处理的邀请会阻止再次发送，被拒绝、撤回或过期后可以重新邀请
 	_, err_findInv := s.friendRepo.FindInvitation(senderID, receiverID)
 	if err_findInv == nil {
 		return errors.New("already send invitation")
@@ -46,28 +104,162 @@ func (s *friendService) SendInvitation(senderID, receiverID uint64) error {
 	if !errors.Is(err_findInv, gorm.ErrRecordNotFound) {
 		return err_findInv
 	}
+	//对方已经发来待处理的邀请时直接接受即可，不再创建反向邀请
+	_, err_findInv = s.friendRepo.FindInvitation(receiverID, senderID)
+	if err_findInv == nil {
+		return errors.New("this user has already invited you, accept the invitation instead")
+	}
+	if !errors.Is(err_findInv, gorm.ErrRecordNotFound) {
+		return err_findInv
+	}
+	message, _, err = s.textFilter.Check(TextFieldMessage, strings.TrimSpace(message))
+	if err != nil {
+		return err
+	}
 
 	invitation := models.Invitation{
 		SenderID:   senderID,
 		ReceiverID: receiverID,
+		Message:    message,
 	}
-	return s.friendRepo.CreateInvitation(&invitation)
+	if err := s.friendRepo.CreateInvitation(&invitation); err != nil {
+		return err
+	}
+	s.publishInvitationEvent(receiverID, senderID, models.EventInvitationReceived, &invitation)
+	return nil
 }
 
 func (s *friendService) AcceptInvitation(invitationID, userID uint64) error {
-	return s.friendRepo.AcceptInvitation(invitationID, userID)
+	if err := s.friendRepo.AcceptInvitation(invitationID, userID); err != nil {
+		return err
+	}
+	invitation, err := s.friendRepo.GetInvitationByID(invitationID)
+	if err != nil {
+		log.Printf("load invitation %d for event failed: %v", invitationID, err)
+		return nil
+	}
+	s.publishInvitationEvent(invitation.SenderID, userID, models.EventInvitationAccepted, invitation)
+	return nil
+}
+
+// publishInvitationEvent 推送失败不影响邀请本身，只记录日志
+func (s *friendService) publishInvitationEvent(toID, fromID uint64, eventType string, invitation *models.Invitation) {
+	from, err := s.userRepo.FindByID(fromID)
+	if err != nil {
+		log.Printf("load user %d for event failed: %v", fromID, err)
+		return
+	}
+	s.hub.Publish(toID, eventType, models.InvitationEventDto{
+		InvitationID: invitation.ID,
+		User:         toUserDto(from),
+		Message:      invitation.Message,
+	})
 }
 
 func (s *friendService) RefuseInvitation(invitationID, userID uint64) error {
 	return s.friendRepo.RefuseInvitation(invitationID, userID)
 }
 
-func (s *friendService) GetReceivedInvitations(userID uint64) ([]models.Invitation, error) {
-	return s.friendRepo.GetInvitationByReceiver(userID, "pending")
+func (s *friendService) CancelInvitation(invitationID, userID uint64) error {
+	return s.friendRepo.CancelInvitation(invitationID, userID)
+}
+
+// ExpireInvitations 由后台任务定期调用
+func (s *friendService) ExpireInvitations() error {
+	count, err := s.friendRepo.ExpireInvitations(time.Now().Add(-s.invitationExpiry))
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		log.Printf("%d invitations expired", count)
+	}
+	return nil
+}
+
+func (s *friendService) GetReceivedInvitations(userID uint64, page, pageSize int) (*models.PageDto, error) {
+	invitations, total, err := s.friendRepo.PageInvitationsByReceiver(userID, models.InvitationPending,
+		page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return s.toInvitationPage(userID, invitations, total, page, pageSize)
 }
 
-func (s *friendService) GetSentInvitations(userID uint64) ([]models.Invitation, error) {
-	return s.friendRepo.GetInvitationBySender(userID, "pending")
+func (s *friendService) GetSentInvitations(userID uint64, page, pageSize int) (*models.PageDto, error) {
+	invitations, total, err := s.friendRepo.PageInvitationsBySender(userID, models.InvitationPending,
+		page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return s.toInvitationPage(userID, invitations, total, page, pageSize)
+}
+
+// toInvitationPage 批量查询双方用户信息和共同好友数，避免逐条查询
+func (s *friendService) toInvitationPage(userID uint64, invitations []models.Invitation, total int64,
+	page, pageSize int) (*models.PageDto, error) {
+	idSet := make(map[uint64]struct{})
+	var others []uint64
+	for _, inv := range invitations {
+		for _, id := range []uint64{inv.SenderID, inv.ReceiverID} {
+			if _, ok := idSet[id]; !ok {
+				idSet[id] = struct{}{}
+				if id != userID {
+					others = append(others, id)
+				}
+			}
+		}
+	}
+
+	ids := make([]uint64, 0, len(idSet))
+	for id := range idSet {
+		ids = append(ids, id)
+	}
+	users, err := s.userRepo.FindByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	userMap := make(map[uint64]models.UserDto, len(users))
+	for _, user := range users {
+		userMap[user.UserID] = toUserDto(&user)
+	}
+
+	mutual, err := s.friendRepo.CountMutualFriends(userID, others)
+	if err != nil {
+		return nil, err
+	}
+	friendListVisible, err := s.privacyService.FilterViewable(userID, others, models.PrivacyFieldFriendList)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range others {
+		if !friendListVisible[id] {
+			mutual[id] = 0
+		}
+	}
+
+	dtos := make([]models.InvitationDto, len(invitations))
+	for i, inv := range invitations {
+		other := inv.SenderID
+		if other == userID {
+			other = inv.ReceiverID
+		}
+		dtos[i] = models.InvitationDto{
+			ID:                inv.ID,
+			Sender:            userMap[inv.SenderID],
+			Receiver:          userMap[inv.ReceiverID],
+			Message:           inv.Message,
+			Status:            inv.Status,
+			MutualFriendCount: mutual[other],
+			CreatedAt:         inv.CreatedAt,
+		}
+	}
+
+	return &models.PageDto{
+		Total:     total,
+		PageIndex: page,
+		PageSize:  pageSize,
+		Data:      dtos,
+	}, nil
 }
 
 func (s *friendService) RemoveFriend(userID, friendID uint64) error {
@@ -85,10 +277,328 @@ func (s *friendService) CheckFriendShip(userID, friendID uint64) (bool, error) {
 	return s.friendRepo.IsFriends(userID, friendID)
 }
 
-func (s *friendService) GetFriendCount(userID int64) (int64, error) {
-	return s.friendRepo.GetFriendCount(uint64(userID))
+func (s *friendService) GetFriendCount(userID uint64) (int64, error) {
+	return s.friendRepo.GetFriendCount(userID)
 }
 
-func (s *friendService) GetFriendList(userID int64) ([]models.User, error) {
-	return s.friendRepo.GetFriendList(uint64(userID))
+// GetFriendList groupID为0时返回全部好友
+func (s *friendService) GetFriendList(userID, groupID uint64) ([]models.FriendDto, error) {
+	var friends []models.User
+	var err error
+	if groupID == 0 {
+		friends, err = s.friendRepo.GetFriendList(userID)
+	} else {
+		if _, err := s.groupRepo.FindGroup(groupID, userID); err != nil {
+			return nil, err
+		}
+		friends, err = s.friendRepo.GetGroupFriendList(userID, groupID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	nicknames, err := s.groupRepo.GetNicknames(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	friendIDs := make([]uint64, len(friends))
+	for i, friend := range friends {
+		friendIDs[i] = friend.UserID
+	}
+	presences, err := s.presenceService.GetPresences(friendIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]models.FriendDto, len(friends))
+	for i := range friends {
+		res[i] = models.FriendDto{
+			UserDto:  toUserDto(&friends[i]),
+			Nickname: nicknames[friends[i].UserID],
+			Presence: presences[friends[i].UserID],
+		}
+	}
+	return res, nil
+}
+
+// GetUserFriendList 查看他人的好友列表，受对方的隐私设置控制
+func (s *friendService) GetUserFriendList(viewerID, userID uint64) ([]models.UserDto, error) {
+	if _, err := s.userRepo.FindByID(userID); err != nil {
+		return nil, err
+	}
+	canView, err := s.privacyService.CanView(viewerID, userID, models.PrivacyFieldFriendList)
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		return nil, ErrPrivacyRestricted
+	}
+
+	friends, err := s.friendRepo.GetFriendList(userID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]models.UserDto, len(friends))
+	for i := range friends {
+		res[i] = toUserDto(&friends[i])
+	}
+	return res, nil
+}
+
+func (s *friendService) CreateGroup(userID uint64, name string) (*models.FriendGroupDto, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("group name can not be blank")
+	}
+	count, err := s.groupRepo.CountGroups(userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxFriendGroups {
+		return nil, fmt.Errorf("at most %d groups", maxFriendGroups)
+	}
+
+	group := &models.FriendGroup{OwnerID: userID, Name: name}
+	if err := s.groupRepo.CreateGroup(group); err != nil {
+		return nil, err
+	}
+	return &models.FriendGroupDto{
+		ID:        group.ID,
+		Name:      group.Name,
+		MemberIDs: []uint64{},
+		CreatedAt: group.CreatedAt,
+	}, nil
+}
+
+func (s *friendService) GetGroups(userID uint64) ([]models.FriendGroupDto, error) {
+	groups, err := s.groupRepo.GetGroups(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	groupIDs := make([]uint64, len(groups))
+	for i, group := range groups {
+		groupIDs[i] = group.ID
+	}
+	members, err := s.groupRepo.GetMembers(groupIDs)
+	if err != nil {
+		return nil, err
+	}
+	memberMap := make(map[uint64][]uint64)
+	for _, member := range members {
+		memberMap[member.GroupID] = append(memberMap[member.GroupID], member.FriendID)
+	}
+
+	res := make([]models.FriendGroupDto, len(groups))
+	for i, group := range groups {
+		memberIDs := memberMap[group.ID]
+		if memberIDs == nil {
+			memberIDs = []uint64{}
+		}
+		res[i] = models.FriendGroupDto{
+			ID:        group.ID,
+			Name:      group.Name,
+			MemberIDs: memberIDs,
+			CreatedAt: group.CreatedAt,
+		}
+	}
+	return res, nil
+}
+
+func (s *friendService) RenameGroup(userID, groupID uint64, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("group name can not be blank")
+	}
+	return s.groupRepo.RenameGroup(groupID, userID, name)
+}
+
+func (s *friendService) DeleteGroup(userID, groupID uint64) error {
+	return s.groupRepo.DeleteGroup(groupID, userID)
+}
+
+// AddGroupMember 只能把好友加入分组
+func (s *friendService) AddGroupMember(userID, groupID, friendID uint64) error {
+	if _, err := s.groupRepo.FindGroup(groupID, userID); err != nil {
+		return err
+	}
+	isFriend, err := s.friendRepo.IsFriends(userID, friendID)
+	if err != nil {
+		return err
+	}
+	if !isFriend {
+		return errors.New("not friend yet")
+	}
+	return s.groupRepo.AddMember(groupID, friendID)
+}
+
+func (s *friendService) RemoveGroupMember(userID, groupID, friendID uint64) error {
+	if _, err := s.groupRepo.FindGroup(groupID, userID); err != nil {
+		return err
+	}
+	return s.groupRepo.RemoveMember(groupID, friendID)
+}
+
+func (s *friendService) SetNickname(userID, friendID uint64, nickname string) error {
+	isFriend, err := s.friendRepo.IsFriends(userID, friendID)
+	if err != nil {
+		return err
+	}
+	if !isFriend {
+		return errors.New("not friend yet")
+	}
+	return s.groupRepo.SetNickname(userID, friendID, strings.TrimSpace(nickname))
+}
+
+func (s *friendService) GetMutualFriends(userID, otherID uint64) ([]models.UserDto, error) {
+	if userID == otherID {
+		return nil, errors.New("can not compare with self")
+	}
+	//共同好友会暴露对方的部分好友列表
+	canView, err := s.privacyService.CanView(userID, otherID, models.PrivacyFieldFriendList)
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		return nil, ErrPrivacyRestricted
+	}
+	users, err := s.friendRepo.GetMutualFriends(userID, otherID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]models.UserDto, len(users))
+	for i := range users {
+		res[i] = toUserDto(&users[i])
+	}
+	return res, nil
+}
+
+// GetSuggestions 候选人来自好友的好友和愿望单重合的人，按共同好友数和相同愿望单游戏数加权排序
+func (s *friendService) GetSuggestions(userID uint64, limit int) ([]models.FriendSuggestionDto, error) {
+	fof, err := s.friendRepo.FindFriendsOfFriends(userID, suggestionPoolSize)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := s.friendRepo.FindSharedWishlistUsers(userID, suggestionPoolSize)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[uint64]bool)
+	var candidates []uint64
+	for _, id := range append(fof, shared...) {
+		if !seen[id] {
+			seen[id] = true
+			candidates = append(candidates, id)
+		}
+	}
+	if len(candidates) == 0 {
+		return []models.FriendSuggestionDto{}, nil
+	}
+
+	mutualCounts, err := s.friendRepo.CountMutualFriends(userID, candidates)
+	if err != nil {
+		return nil, err
+	}
+	wishlistCounts, err := s.friendRepo.CountSharedWishlist(userID, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	// 对方隐藏了好友列表或愿望单时，对应的计数不能参与推荐也不能返回
+	friendListVisible, err := s.privacyService.FilterViewable(userID, candidates, models.PrivacyFieldFriendList)
+	if err != nil {
+		return nil, err
+	}
+	wishlistVisible, err := s.privacyService.FilterViewable(userID, candidates, models.PrivacyFieldWishlist)
+	if err != nil {
+		return nil, err
+	}
+	score := func(id uint64) int64 {
+		return mutualCounts[id]*suggestionMutualWeight + wishlistCounts[id]*suggestionWishlistWeight
+	}
+	visible := candidates[:0]
+	for _, id := range candidates {
+		if !friendListVisible[id] {
+			mutualCounts[id] = 0
+		}
+		if !wishlistVisible[id] {
+			wishlistCounts[id] = 0
+		}
+		if score(id) > 0 {
+			visible = append(visible, id)
+		}
+	}
+	candidates = visible
+	if len(candidates) == 0 {
+		return []models.FriendSuggestionDto{}, nil
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return score(candidates[i]) > score(candidates[j])
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	users, err := s.userRepo.FindByIDs(candidates)
+	if err != nil {
+		return nil, err
+	}
+	userMap := make(map[uint64]*models.User, len(users))
+	for i := range users {
+		userMap[users[i].UserID] = &users[i]
+	}
+
+	res := make([]models.FriendSuggestionDto, 0, len(candidates))
+	for _, id := range candidates {
+		user, ok := userMap[id]
+		if !ok {
+			continue
+		}
+		res = append(res, models.FriendSuggestionDto{
+			User:                toUserDto(user),
+			MutualFriendCount:   mutualCounts[id],
+			SharedWishlistCount: wishlistCounts[id],
+		})
+	}
+	return res, nil
+}
+
+// BlockUser 屏蔽后解除好友关系并撤回双方之间的待处理邀请
+func (s *friendService) BlockUser(userID, targetID uint64) error {
+	if userID == targetID {
+		return errors.New("can not block self")
+	}
+	if _, err := s.userRepo.FindByID(targetID); err != nil {
+		return err
+	}
+
+	return s.friendRepo.BlockUser(userID, targetID)
+}
+
+func (s *friendService) UnblockUser(userID, targetID uint64) error {
+	return s.friendRepo.DeleteBlock(userID, targetID)
+}
+
+func (s *friendService) GetBlockedList(userID uint64) ([]models.UserDto, error) {
+	users, err := s.friendRepo.GetBlockedList(userID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]models.UserDto, len(users))
+	for i := range users {
+		res[i] = toUserDto(&users[i])
+	}
+	return res, nil
+}
+
+func toUserDto(user *models.User) models.UserDto {
+	return models.UserDto{
+		UserID:   user.UserID,
+		UserName: user.UserName,
+		NickName: user.NickName,
+		Avatar:   user.Avatar,
+	}
 }
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"log"
+	"time"
+)
+
+// RunPeriodically 在后台goroutine中每隔interval执行一次job，出错只记录日志不退出
+func RunPeriodically(name string, interval time.Duration, job func() error) {
+	if interval <= 0 {
+		log.Printf("job %s disabled", name)
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := job(); err != nil {
+				log.Printf("job %s failed: %v", name, err)
+			}
+		}
+	}()
+}
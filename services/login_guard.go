@@ -0,0 +1,201 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// LoginThrottledError 登录被限制时返回，Locked为true表示账号/IP已被临时锁定，
+// 否则表示处于指数退避的等待期内
+type LoginThrottledError struct {
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("account locked, retry after %s", e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("too many failed attempts, retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+// 记录数超过该值时清理一次过期记录，避免随机用户名撑爆内存
+const maxTrackedAttempts = 10000
+
+// loginAttempt pending为已通过Check、还未得出结果的登录次数
+type loginAttempt struct {
+	failures    int
+	pending     int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// LoginGuard 在内存中按用户名和IP分别记录登录失败次数
+type LoginGuard struct {
+	mu          sync.Mutex
+	attempts    map[string]*loginAttempt
+	maxFailures int
+	maxPerIP    int
+	backoffBase time.Duration
+	lockout     time.Duration
+}
+
+func NewLoginGuard(maxFailures, maxPerIP int, backoffBase, lockout time.Duration) *LoginGuard {
+	return &LoginGuard{
+		attempts:    make(map[string]*loginAttempt),
+		maxFailures: maxFailures,
+		maxPerIP:    maxPerIP,
+		backoffBase: backoffBase,
+		lockout:     lockout,
+	}
+}
+
+func userKey(username string) string { return "user:" + username }
+func ipKey(ip string) string         { return "ip:" + ip }
+
+// Check 在校验密码前调用，用户名或IP任一被限制都拒绝本次登录。
+// 通过时立即为本次登录占位，之后必须调用RecordFailure或RecordSuccess释放，
+// 这样同时发起的大量请求不会在得出结果前全部通过检查
+func (g *LoginGuard) Check(username, ip string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	if err := g.checkLocked(userKey(username), g.maxFailures, now); err != nil {
+		return err
+	}
+	if err := g.checkLocked(ipKey(ip), g.maxPerIP, now); err != nil {
+		return err
+	}
+	//同一用户名同一时间只允许一次尝试，IP下的多个用户可以同时登录，但待定次数计入上限
+	if attempt := g.attempts[userKey(username)]; attempt != nil && attempt.pending > 0 {
+		return &LoginThrottledError{RetryAfter: g.backoffBase}
+	}
+
+	if len(g.attempts) > maxTrackedAttempts {
+		g.sweep(now)
+	}
+	for _, key := range []string{userKey(username), ipKey(ip)} {
+		attempt := g.current(key, now)
+		if attempt == nil {
+			attempt = &loginAttempt{}
+			g.attempts[key] = attempt
+		}
+		attempt.pending++
+	}
+	return nil
+}
+
+func (g *LoginGuard) checkLocked(key string, max int, now time.Time) error {
+	attempt := g.current(key, now)
+	if attempt == nil {
+		return nil
+	}
+	if now.Before(attempt.lockedUntil) {
+		return &LoginThrottledError{Locked: true, RetryAfter: attempt.lockedUntil.Sub(now)}
+	}
+	if next := attempt.lastFailure.Add(g.backoff(attempt.failures)); now.Before(next) {
+		return &LoginThrottledError{RetryAfter: next.Sub(now)}
+	}
+	if max > 0 && attempt.failures+attempt.pending >= max {
+		return &LoginThrottledError{RetryAfter: g.backoff(attempt.failures + attempt.pending)}
+	}
+	return nil
+}
+
+// current 返回仍然有效的记录，锁定结束或长时间没有失败的记录直接丢弃，有待定登录的记录保留
+func (g *LoginGuard) current(key string, now time.Time) *loginAttempt {
+	attempt, ok := g.attempts[key]
+	if !ok {
+		return nil
+	}
+	if attempt.pending > 0 {
+		return attempt
+	}
+	expired := !attempt.lockedUntil.IsZero() && !now.Before(attempt.lockedUntil)
+	stale := attempt.lockedUntil.IsZero() && now.Sub(attempt.lastFailure) > g.lockout
+	if expired || stale {
+		delete(g.attempts, key)
+		return nil
+	}
+	return attempt
+}
+
+// backoff 第n次失败后需要等待 base*2^(n-1)，最长不超过锁定时间
+func (g *LoginGuard) backoff(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+	wait := g.backoffBase
+	for i := 1; i < failures && wait < g.lockout; i++ {
+		wait *= 2
+	}
+	if wait > g.lockout {
+		wait = g.lockout
+	}
+	return wait
+}
+
+// RecordFailure 释放Check的占位并记一次失败
+func (g *LoginGuard) RecordFailure(username, ip string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	g.release(userKey(username))
+	g.release(ipKey(ip))
+	g.recordFailure(userKey(username), g.maxFailures, now)
+	g.recordFailure(ipKey(ip), g.maxPerIP, now)
+}
+
+func (g *LoginGuard) sweep(now time.Time) {
+	for key := range g.attempts {
+		g.current(key, now)
+	}
+}
+
+// release 释放一次占位，没有失败记录也没有其他待定登录时删除该记录
+func (g *LoginGuard) release(key string) {
+	attempt, ok := g.attempts[key]
+	if !ok || attempt.pending == 0 {
+		return
+	}
+	attempt.pending--
+	if attempt.pending == 0 && attempt.failures == 0 {
+		delete(g.attempts, key)
+	}
+}
+
+func (g *LoginGuard) recordFailure(key string, max int, now time.Time) {
+	attempt := g.current(key, now)
+	if attempt == nil {
+		attempt = &loginAttempt{}
+		g.attempts[key] = attempt
+	}
+	attempt.failures++
+	attempt.lastFailure = now
+	if max > 0 && attempt.failures >= max {
+		attempt.lockedUntil = now.Add(g.lockout)
+	}
+}
+
+// RecordSuccess 释放Check的占位并清除该用户名的失败记录，IP记录保留以防止撞库
+func (g *LoginGuard) RecordSuccess(username, ip string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.attempts, userKey(username))
+	g.release(ipKey(ip))
+}
+
+// Unlock 管理员手动解锁，ip为空时只解锁用户名
+func (g *LoginGuard) Unlock(username, ip string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if username != "" {
+		delete(g.attempts, userKey(username))
+	}
+	if ip != "" {
+		delete(g.attempts, ipKey(ip))
+	}
+}
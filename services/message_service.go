@@ -0,0 +1,188 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"steam-backend/config"
+	"steam-backend/models"
+	"steam-backend/repositories"
+	"steam-backend/utils"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// RateLimitedError 操作过于频繁时返回
+type RateLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("too many requests, retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+type MessageService interface {
+	SendMessage(senderID, receiverID uint64, content string) (*models.MessageDto, error)
+	GetConversation(userID, otherID, cursor uint64, limit int) (*models.MessagePageDto, error)
+	GetConversations(userID uint64, page, pageSize int) (*models.PageDto, error)
+	GetUnreadCount(userID uint64) (int64, error)
+	MarkRead(userID, otherID uint64) error
+}
+
+type messageService struct {
+	messageRepo repositories.MessageRepository
+	friendRepo  repositories.FriendRepository
+	userRepo    repositories.UserRepository
+	hub         *EventHub
+	textFilter  TextFilterService
+	limiter     *utils.RateLimiter
+	rateLimit   int
+	maxLength   int
+}
+
+func NewMessageService(messageRepo repositories.MessageRepository, friendRepo repositories.FriendRepository,
+	userRepo repositories.UserRepository, hub *EventHub, textFilter TextFilterService, cfg config.Config) MessageService {
+	return &messageService{
+		messageRepo: messageRepo,
+		friendRepo:  friendRepo,
+		userRepo:    userRepo,
+		hub:         hub,
+		textFilter:  textFilter,
+		limiter:     utils.NewRateLimiter(time.Minute),
+		rateLimit:   cfg.MessageRateLimit,
+		maxLength:   cfg.MessageMaxLength,
+	}
+}
+
+func (s *messageService) SendMessage(senderID, receiverID uint64, content string) (*models.MessageDto, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, errors.New("message can not be blank")
+	}
+	if utf8.RuneCountInString(content) > s.maxLength {
+		return nil, fmt.Errorf("message is longer than %d characters", s.maxLength)
+	}
+	isFriend, err := s.friendRepo.IsFriends(senderID, receiverID)
+	if err != nil {
+		return nil, err
+	}
+	if !isFriend {
+		return nil, errors.New("not friend yet")
+	}
+	if allowed, retryAfter := s.limiter.Allow(strconv.FormatUint(senderID, 10), s.rateLimit); !allowed {
+		return nil, &RateLimitedError{RetryAfter: retryAfter}
+	}
+	content, _, err = s.textFilter.Check(TextFieldMessage, content)
+	if err != nil {
+		return nil, err
+	}
+
+	message := models.Message{
+		SenderID:   senderID,
+		ReceiverID: receiverID,
+		Content:    content,
+	}
+	if err := s.messageRepo.CreateMessage(&message); err != nil {
+		return nil, err
+	}
+
+	dto := toMessageDto(&message)
+	s.hub.Publish(receiverID, models.EventMessageReceived, dto)
+	return &dto, nil
+}
+
+// GetConversation 解除好友后仍可查看历史消息，只是不能再发送
+func (s *messageService) GetConversation(userID, otherID, cursor uint64, limit int) (*models.MessagePageDto, error) {
+	messages, err := s.messageRepo.GetConversation(userID, otherID, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &models.MessagePageDto{Messages: make([]models.MessageDto, len(messages))}
+	for i := range messages {
+		res.Messages[i] = toMessageDto(&messages[i])
+	}
+	if len(messages) == limit {
+		res.NextCursor = messages[len(messages)-1].ID
+	}
+	return res, nil
+}
+
+func (s *messageService) GetConversations(userID uint64, page, pageSize int) (*models.PageDto, error) {
+	messages, total, err := s.messageRepo.PageConversations(userID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	otherIDs := make([]uint64, len(messages))
+	for i, message := range messages {
+		otherIDs[i] = message.SenderID
+		if message.SenderID == userID {
+			otherIDs[i] = message.ReceiverID
+		}
+	}
+	users, err := s.userRepo.FindByIDs(otherIDs)
+	if err != nil {
+		return nil, err
+	}
+	userMap := make(map[uint64]*models.User, len(users))
+	for i := range users {
+		userMap[users[i].UserID] = &users[i]
+	}
+	unread, err := s.messageRepo.CountUnreadBySender(userID, otherIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	conversations := make([]models.ConversationDto, 0, len(messages))
+	for i := range messages {
+		user, ok := userMap[otherIDs[i]]
+		if !ok {
+			continue
+		}
+		conversations = append(conversations, models.ConversationDto{
+			User:        toUserDto(user),
+			LastMessage: toMessageDto(&messages[i]),
+			UnreadCount: unread[otherIDs[i]],
+		})
+	}
+
+	return &models.PageDto{
+		Total:     total,
+		PageIndex: page,
+		PageSize:  pageSize,
+		Data:      conversations,
+	}, nil
+}
+
+func (s *messageService) GetUnreadCount(userID uint64) (int64, error) {
+	return s.messageRepo.CountUnread(userID)
+}
+
+// MarkRead 标记已读后通知对方，用于显示已读回执
+func (s *messageService) MarkRead(userID, otherID uint64) error {
+	lastID, err := s.messageRepo.MarkRead(userID, otherID)
+	if err != nil {
+		return err
+	}
+	if lastID == 0 {
+		return nil
+	}
+	s.hub.Publish(otherID, models.EventMessageRead, models.MessageReadEventDto{
+		ReaderID:   userID,
+		LastReadID: lastID,
+	})
+	return nil
+}
+
+func toMessageDto(message *models.Message) models.MessageDto {
+	return models.MessageDto{
+		ID:         message.ID,
+		SenderID:   message.SenderID,
+		ReceiverID: message.ReceiverID,
+		Content:    message.Content,
+		ReadAt:     message.ReadAt,
+		CreatedAt:  message.CreatedAt,
+	}
+}
@@ -0,0 +1,347 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"steam-backend/config"
+	"steam-backend/models"
+	"steam-backend/repositories"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ModerationService interface {
+	Report(reporterID uint64, dto models.ReportRequestDto) error
+	GetReports(filter models.ReportFilterDto, page, pageSize int) (*models.PageDto, error)
+	ResolveReport(moderatorID, reportID uint64, dto models.ResolveReportRequestDto) error
+	LiftBan(userID uint64) error
+	ActiveBan(userID uint64) (*models.UserBan, error)
+	PurgeBanCache() error
+}
+
+// AccountBannedError 被封禁用户登录时返回
+type AccountBannedError struct {
+	Ban *models.UserBan
+}
+
+func (e *AccountBannedError) Error() string {
+	return "account is banned until " + e.Ban.ExpiresAt.Format(time.RFC3339)
+}
+
+// banCacheEntry ban为nil表示查询时用户未被封禁
+type banCacheEntry struct {
+	ban       *models.UserBan
+	checkedAt time.Time
+}
+
+type moderationService struct {
+	moderationRepo repositories.ModerationRepository
+	userRepo       repositories.UserRepository
+	reviewRepo     repositories.ReviewRepository
+	commentRepo    repositories.ProfileCommentRepository
+	avatarService  AvatarService
+	hub            *EventHub
+	banCacheTTL    time.Duration
+
+	mu       sync.Mutex
+	banCache map[uint64]banCacheEntry
+}
+
+func NewModerationService(moderationRepo repositories.ModerationRepository, userRepo repositories.UserRepository,
+	reviewRepo repositories.ReviewRepository, commentRepo repositories.ProfileCommentRepository,
+	avatarService AvatarService, hub *EventHub, cfg config.Config) ModerationService {
+	return &moderationService{
+		moderationRepo: moderationRepo,
+		userRepo:       userRepo,
+		reviewRepo:     reviewRepo,
+		commentRepo:    commentRepo,
+		avatarService:  avatarService,
+		hub:            hub,
+		banCacheTTL:    cfg.BanCacheTTL,
+		banCache:       make(map[uint64]banCacheEntry),
+	}
+}
+
+// Report 同一用户对同一内容只能有一条未处理的举报，不能举报自己
+func (s *moderationService) Report(reporterID uint64, dto models.ReportRequestDto) error {
+	targetUserID, err := s.targetOwner(dto.TargetType, dto.TargetID)
+	if err != nil {
+		return err
+	}
+	if targetUserID == reporterID {
+		return errors.New("can not report yourself")
+	}
+	exists, err := s.moderationRepo.HasOpenReport(reporterID, dto.TargetType, dto.TargetID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.New("already reported")
+	}
+
+	return s.moderationRepo.CreateReport(&models.Report{
+		ReporterID:   &reporterID,
+		TargetType:   dto.TargetType,
+		TargetID:     dto.TargetID,
+		TargetUserID: targetUserID,
+		Reason:       dto.Reason,
+		Detail:       strings.TrimSpace(dto.Detail),
+		Status:       models.ReportStatusOpen,
+	})
+}
+
+// targetOwner 返回被举报内容的发布者
+func (s *moderationService) targetOwner(targetType string, targetID uint64) (uint64, error) {
+	switch targetType {
+	case models.ReportTargetProfile, models.ReportTargetNickname, models.ReportTargetAvatar:
+		user, err := s.userRepo.FindByID(targetID)
+		if err != nil {
+			return 0, err
+		}
+		return user.UserID, nil
+	case models.ReportTargetReview:
+		review, err := s.reviewRepo.FindReview(targetID)
+		if err != nil {
+			return 0, err
+		}
+		return review.UserID, nil
+	case models.ReportTargetComment:
+		comment, err := s.commentRepo.FindComment(targetID)
+		if err != nil {
+			return 0, err
+		}
+		return comment.AuthorID, nil
+	}
+	return 0, errors.New("invalid target type")
+}
+
+func (s *moderationService) GetReports(filter models.ReportFilterDto, page, pageSize int) (*models.PageDto, error) {
+	if filter.Status == "" {
+		filter.Status = models.ReportStatusOpen
+	}
+	reports, total, err := s.moderationRepo.PageReports(filter, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]uint64, 0, len(reports)*2)
+	for _, report := range reports {
+		if report.ReporterID != nil {
+			userIDs = append(userIDs, *report.ReporterID)
+		}
+		userIDs = append(userIDs, report.TargetUserID)
+	}
+	users, err := s.userRepo.FindByIDs(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	userMap := make(map[uint64]models.UserDto, len(users))
+	for i := range users {
+		userMap[users[i].UserID] = toUserDto(&users[i])
+	}
+
+	res := make([]models.ReportDto, len(reports))
+	for i, report := range reports {
+		var reporter *models.UserDto
+		if report.ReporterID != nil {
+			if user, ok := userMap[*report.ReporterID]; ok {
+				reporter = &user
+			}
+		}
+		res[i] = models.ReportDto{
+			ID:          report.ID,
+			Reporter:    reporter,
+			TargetType:  report.TargetType,
+			TargetID:    report.TargetID,
+			TargetUser:  userMap[report.TargetUserID],
+			Reason:      report.Reason,
+			Detail:      report.Detail,
+			Status:      report.Status,
+			ModeratorID: report.ModeratorID,
+			Action:      report.Action,
+			Note:        report.Note,
+			CreatedAt:   report.CreatedAt,
+			ResolvedAt:  report.ResolvedAt,
+		}
+	}
+	return &models.PageDto{
+		Total:     total,
+		PageIndex: page,
+		PageSize:  pageSize,
+		Data:      res,
+	}, nil
+}
+
+// ResolveReport 处理结果对同一内容的其他未处理举报同样生效；先结案再执行处理动作，
+// 两个版主同时处理同一举报时只有一人的动作生效，动作失败时恢复为未处理
+func (s *moderationService) ResolveReport(moderatorID, reportID uint64, dto models.ResolveReportRequestDto) error {
+	report, err := s.moderationRepo.FindReport(reportID)
+	if err != nil {
+		return err
+	}
+	if report.Status != models.ReportStatusOpen {
+		return errors.New("report already handled")
+	}
+	note := strings.TrimSpace(dto.Note)
+
+	status := models.ReportStatusResolved
+	switch dto.Action {
+	case models.ModerationDismiss:
+		status = models.ReportStatusDismissed
+	case models.ModerationHide, models.ModerationWarn:
+	case models.ModerationBan:
+		if dto.BanHours <= 0 {
+			return errors.New("banHours is required")
+		}
+	default:
+		return errors.New("invalid action")
+	}
+
+	ids, err := s.moderationRepo.ResolveReports(report.ID, status, dto.Action, moderatorID, note)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return errors.New("report already handled")
+	}
+	if err := s.applyAction(moderatorID, report, dto, note); err != nil {
+		if reopenErr := s.moderationRepo.ReopenReports(ids); reopenErr != nil {
+			log.Printf("reopen reports %v failed: %v", ids, reopenErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *moderationService) applyAction(moderatorID uint64, report *models.Report, dto models.ResolveReportRequestDto,
+	note string) error {
+	switch dto.Action {
+	case models.ModerationHide:
+		return s.hideContent(report)
+	case models.ModerationWarn:
+		err := s.moderationRepo.CreateWarning(&models.UserWarning{
+			UserID:      report.TargetUserID,
+			ModeratorID: moderatorID,
+			ReportID:    report.ID,
+			Message:     note,
+		})
+		if err != nil {
+			return err
+		}
+		s.hub.Publish(report.TargetUserID, models.EventModerationWarning, models.ModerationWarningEventDto{
+			ReportID: report.ID,
+			Message:  note,
+		})
+	case models.ModerationBan:
+		err := s.moderationRepo.CreateBan(&models.UserBan{
+			UserID:      report.TargetUserID,
+			ModeratorID: moderatorID,
+			ReportID:    report.ID,
+			Reason:      note,
+			ExpiresAt:   time.Now().Add(time.Duration(dto.BanHours) * time.Hour),
+		})
+		if err != nil {
+			return err
+		}
+		s.invalidateBan(report.TargetUserID)
+	}
+	return nil
+}
+
+// hideContent 评测和留言直接删除，资料类内容恢复为默认值，只写入对应的列；内容已被删除时视为成功
+func (s *moderationService) hideContent(report *models.Report) error {
+	switch report.TargetType {
+	case models.ReportTargetReview:
+		review, err := s.reviewRepo.FindReview(report.TargetID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return s.reviewRepo.DeleteReview(review)
+	case models.ReportTargetComment:
+		return s.commentRepo.DeleteComment(report.TargetID)
+	}
+
+	user, err := s.userRepo.FindByID(report.TargetUserID)
+	if err != nil {
+		return err
+	}
+	switch report.TargetType {
+	case models.ReportTargetProfile:
+		user.Bio = ""
+		return s.userRepo.UpdateColumns(user, "bio")
+	case models.ReportTargetNickname:
+		user.NickName = user.UserName
+		return s.userRepo.UpdateColumns(user, "nickName")
+	case models.ReportTargetAvatar:
+		oldAvatar := user.Avatar
+		user.Avatar = ""
+		if err := s.userRepo.UpdateColumns(user, "avatar"); err != nil {
+			return err
+		}
+		//头像已经撤下，文件删除失败只记录日志
+		if err := s.avatarService.DeleteAvatar(userAvatarPrefix(user.UserID), oldAvatar); err != nil {
+			log.Printf("delete hidden avatar of user %d failed: %v", user.UserID, err)
+		}
+	}
+	return nil
+}
+
+func (s *moderationService) LiftBan(userID uint64) error {
+	lifted, err := s.moderationRepo.LiftBans(userID)
+	if err != nil {
+		return err
+	}
+	if lifted == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	s.invalidateBan(userID)
+	return nil
+}
+
+// ActiveBan 每次登录请求都会调用，查询结果缓存banCacheTTL，未被封禁时返回nil
+func (s *moderationService) ActiveBan(userID uint64) (*models.UserBan, error) {
+	now := time.Now()
+	s.mu.Lock()
+	entry, ok := s.banCache[userID]
+	s.mu.Unlock()
+	if ok && now.Sub(entry.checkedAt) < s.banCacheTTL {
+		if entry.ban != nil && !entry.ban.ExpiresAt.After(now) {
+			return nil, nil
+		}
+		return entry.ban, nil
+	}
+
+	ban, err := s.moderationRepo.FindActiveBan(userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.banCache[userID] = banCacheEntry{ban: ban, checkedAt: now}
+	s.mu.Unlock()
+	return ban, nil
+}
+
+// PurgeBanCache 清理过期的缓存，避免缓存随访问用户数无限增长
+func (s *moderationService) PurgeBanCache() error {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for userID, entry := range s.banCache {
+		if now.Sub(entry.checkedAt) >= s.banCacheTTL {
+			delete(s.banCache, userID)
+		}
+	}
+	return nil
+}
+
+func (s *moderationService) invalidateBan(userID uint64) {
+	s.mu.Lock()
+	delete(s.banCache, userID)
+	s.mu.Unlock()
+}
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/url"
+	"steam-backend/config"
+	"steam-backend/models"
+	"steam-backend/repositories"
+	"steam-backend/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OAuthError Code取RFC 6749中定义的错误码，如invalid_request、invalid_grant
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+func oauthErr(code, description string) error {
+	return &OAuthError{Code: code, Description: description}
+}
+
+type OAuthService interface {
+	RegisterClient(ownerID uint64, req *models.OAuthClientRequestDto) (*models.OAuthClientDto, error)
+	GetClients(ownerID uint64) ([]models.OAuthClientDto, error)
+	DeleteClient(clientID string, ownerID uint64) error
+	PrepareConsent(req *models.OAuthAuthorizeRequestDto) (*models.OAuthConsentDto, error)
+	Authorize(userID uint64, req *models.OAuthAuthorizeRequestDto, approve bool) (string, error)
+	ExchangeCode(req *models.OAuthTokenRequestDto) (*models.OAuthTokenResponseDto, error)
+	PurgeExpiredCodes() error
+}
+
+type oauthService struct {
+	oauthRepo repositories.OAuthRepository
+	config    config.Config
+}
+
+func NewOAuthService(repo repositories.OAuthRepository, conf config.Config) OAuthService {
+	return &oauthService{
+		oauthRepo: repo,
+		config:    conf,
+	}
+}
+
+func (s *oauthService) RegisterClient(ownerID uint64, req *models.OAuthClientRequestDto) (*models.OAuthClientDto, error) {
+	for _, scope := range req.Scopes {
+		if !models.IsValidScope(scope) {
+			return nil, errors.New("unknown scope: " + scope)
+		}
+	}
+	for _, uri := range req.RedirectURIs {
+		if !isValidRedirectURI(uri) {
+			return nil, errors.New("invalid redirect uri: " + uri)
+		}
+	}
+
+	clientID, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	client := &models.OAuthClient{
+		ClientID:     clientID,
+		Name:         req.Name,
+		OwnerID:      ownerID,
+		RedirectURIs: strings.Join(req.RedirectURIs, "\n"),
+		Scopes:       strings.Join(req.Scopes, " "),
+	}
+
+	var secret string
+	if req.Confidential {
+		secret, err = randomHex(32)
+		if err != nil {
+			return nil, err
+		}
+		client.SecretHash, err = HashPassword(secret)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.oauthRepo.CreateClient(client); err != nil {
+		return nil, err
+	}
+
+	dto := convertToOAuthClientDto(client)
+	dto.ClientSecret = secret
+	return &dto, nil
+}
+
+// isValidRedirectURI 前端会直接跳转到redirect_uri，只允许https，
+// http只允许回环地址方便本地开发，其他scheme(javascript:、data:等)一律拒绝
+func isValidRedirectURI(uri string) bool {
+	if strings.ContainsAny(uri, "\n#") {
+		return false
+	}
+	u, err := url.Parse(uri)
+	if err != nil || u.Host == "" || u.User != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "https":
+		return true
+	case "http":
+		host := u.Hostname()
+		if strings.EqualFold(host, "localhost") {
+			return true
+		}
+		ip := net.ParseIP(host)
+		return ip != nil && ip.IsLoopback()
+	}
+	return false
+}
+
+func (s *oauthService) GetClients(ownerID uint64) ([]models.OAuthClientDto, error) {
+	clients, err := s.oauthRepo.GetClientsByOwner(ownerID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]models.OAuthClientDto, len(clients))
+	for i := range clients {
+		res[i] = convertToOAuthClientDto(&clients[i])
+	}
+	return res, nil
+}
+
+func (s *oauthService) DeleteClient(clientID string, ownerID uint64) error {
+	return s.oauthRepo.DeleteClient(clientID, ownerID)
+}
+
+// PrepareConsent 校验授权请求，返回给前端展示的应用名和权限
+func (s *oauthService) PrepareConsent(req *models.OAuthAuthorizeRequestDto) (*models.OAuthConsentDto, error) {
+	client, scopes, err := s.validateAuthorizeRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return &models.OAuthConsentDto{
+		ClientID:   client.ClientID,
+		ClientName: client.Name,
+		Scopes:     scopes,
+	}, nil
+}
+
+// Authorize 用户确认授权后生成授权码，返回应当跳转的redirect_uri(带code或error)
+func (s *oauthService) Authorize(userID uint64, req *models.OAuthAuthorizeRequestDto, approve bool) (string, error) {
+	_, scopes, err := s.validateAuthorizeRequest(req)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+
+	if !approve {
+		params.Set("error", "access_denied")
+		return appendQuery(req.RedirectURI, params), nil
+	}
+
+	code, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+	authCode := &models.OAuthAuthorizationCode{
+		CodeHash:      hashCode(code),
+		ClientID:      req.ClientID,
+		UserID:        userID,
+		RedirectURI:   req.RedirectURI,
+		Scope:         strings.Join(scopes, " "),
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     time.Now().Add(s.config.OAuthCodeTTL),
+	}
+	if err := s.oauthRepo.CreateCode(authCode); err != nil {
+		return "", err
+	}
+
+	params.Set("code", code)
+	return appendQuery(req.RedirectURI, params), nil
+}
+
+func (s *oauthService) ExchangeCode(req *models.OAuthTokenRequestDto) (*models.OAuthTokenResponseDto, error) {
+	if req.GrantType != "authorization_code" {
+		return nil, oauthErr("unsupported_grant_type", "only authorization_code is supported")
+	}
+
+	client, err := s.oauthRepo.FindClient(req.ClientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, oauthErr("invalid_client", "unknown client")
+		}
+		return nil, err
+	}
+	if client.SecretHash != "" && !CheckPassword(req.ClientSecret, client.SecretHash) {
+		return nil, oauthErr("invalid_client", "client authentication failed")
+	}
+
+	code, err := s.oauthRepo.ConsumeCode(hashCode(req.Code))
+	if err != nil {
+		if errors.Is(err, repositories.ErrOAuthCodeInvalid) || errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, oauthErr("invalid_grant", "authorization code invalid or expired")
+		}
+		return nil, err
+	}
+	if code.ClientID != client.ClientID || code.RedirectURI != req.RedirectURI {
+		return nil, oauthErr("invalid_grant", "authorization code was issued to another client or redirect uri")
+	}
+	if !verifyCodeChallenge(req.CodeVerifier, code.CodeChallenge) {
+		return nil, oauthErr("invalid_grant", "code_verifier does not match code_challenge")
+	}
+
+	token, err := utils.GenerateOAuthToken(code.UserID, client.ClientID, code.Scope,
+		s.config.OAuthTokenTTL, s.config.JWTSecret)
+	if err != nil {
+		return nil, err
+	}
+	return &models.OAuthTokenResponseDto{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.config.OAuthTokenTTL.Seconds()),
+		Scope:       code.Scope,
+	}, nil
+}
+
+func (s *oauthService) PurgeExpiredCodes() error {
+	return s.oauthRepo.DeleteExpiredCodes(time.Now())
+}
+
+func (s *oauthService) validateAuthorizeRequest(req *models.OAuthAuthorizeRequestDto) (*models.OAuthClient, []string, error) {
+	if req.ResponseType != "code" {
+		return nil, nil, oauthErr("unsupported_response_type", "only code is supported")
+	}
+	//只支持S256，plain等于把verifier明文放在前端url里
+	if req.CodeChallengeMethod != "S256" {
+		return nil, nil, oauthErr("invalid_request", "code_challenge_method must be S256")
+	}
+	if len(req.CodeChallenge) < 43 || len(req.CodeChallenge) > 128 {
+		return nil, nil, oauthErr("invalid_request", "invalid code_challenge")
+	}
+
+	client, err := s.oauthRepo.FindClient(req.ClientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, oauthErr("invalid_client", "unknown client")
+		}
+		return nil, nil, err
+	}
+
+	redirectOK := false
+	for _, uri := range strings.Split(client.RedirectURIs, "\n") {
+		if uri == req.RedirectURI {
+			redirectOK = true
+			break
+		}
+	}
+	if !redirectOK {
+		return nil, nil, oauthErr("invalid_request", "redirect_uri not registered")
+	}
+
+	allowed := strings.Fields(client.Scopes)
+	scopes := strings.Fields(req.Scope)
+	if len(scopes) == 0 {
+		return nil, nil, oauthErr("invalid_scope", "scope is required")
+	}
+	for _, scope := range scopes {
+		if !containsString(allowed, scope) {
+			return nil, nil, oauthErr("invalid_scope", "scope not allowed: "+scope)
+		}
+	}
+	return client, scopes, nil
+}
+
+func verifyCodeChallenge(verifier, challenge string) bool {
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
+
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+func appendQuery(rawURL string, params url.Values) string {
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+	}
+	return rawURL + sep + params.Encode()
+}
+
+func containsString(list []string, target string) bool {
+	for _, item := range list {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
+
+func convertToOAuthClientDto(client *models.OAuthClient) models.OAuthClientDto {
+	return models.OAuthClientDto{
+		ClientID:     client.ClientID,
+		Name:         client.Name,
+		RedirectURIs: strings.Split(client.RedirectURIs, "\n"),
+		Scopes:       strings.Fields(client.Scopes),
+		Confidential: client.SecretHash != "",
+		CreatedAt:    client.CreatedAt,
+	}
+}
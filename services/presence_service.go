@@ -0,0 +1,122 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"steam-backend/models"
+	"steam-backend/repositories"
+	"steam-backend/storage"
+	"time"
+)
+
+type PresenceService interface {
+	Heartbeat(userID uint64, status string, appID uint64) error
+	GoOffline(userID uint64) error
+	GetPresences(userIDs []uint64) (map[uint64]models.PresenceDto, error)
+	SweepExpired() error
+}
+
+type presenceService struct {
+	store      storage.PresenceStore
+	appRepo    repositories.AppRepository
+	friendRepo repositories.FriendRepository
+	hub        *EventHub
+}
+
+func NewPresenceService(store storage.PresenceStore, appRepo repositories.AppRepository,
+	friendRepo repositories.FriendRepository, hub *EventHub) PresenceService {
+	return &presenceService{store: store, appRepo: appRepo, friendRepo: friendRepo, hub: hub}
+}
+
+// Heartbeat 游戏中必须带上存在的AppID，其他状态忽略AppID
+func (s *presenceService) Heartbeat(userID uint64, status string, appID uint64) error {
+	switch status {
+	case models.PresenceInGame:
+		if appID == 0 {
+			return errors.New("appId is required when in game")
+		}
+		if _, err := s.appRepo.FindByID(appID); err != nil {
+			return err
+		}
+	case models.PresenceOnline, models.PresenceAway:
+		appID = 0
+	default:
+		return errors.New("invalid status")
+	}
+
+	previous, err := s.store.GetMany([]uint64{userID})
+	if err != nil {
+		return err
+	}
+	err = s.store.Set(userID, models.Presence{
+		Status:    status,
+		AppID:     appID,
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	//从离线变为在线时通知好友
+	if _, online := previous[userID]; !online {
+		s.notifyFriendsOnline(userID)
+	}
+	return nil
+}
+
+func (s *presenceService) notifyFriendsOnline(userID uint64) {
+	friends, err := s.friendRepo.GetFriendList(userID)
+	if err != nil {
+		log.Printf("load friends of %d for event failed: %v", userID, err)
+		return
+	}
+	for _, friend := range friends {
+		s.hub.Publish(friend.UserID, models.EventFriendOnline, models.FriendOnlineEventDto{UserID: userID})
+	}
+}
+
+func (s *presenceService) GoOffline(userID uint64) error {
+	return s.store.Delete(userID)
+}
+
+// GetPresences 没有记录的用户返回offline，并补全正在玩的游戏名
+func (s *presenceService) GetPresences(userIDs []uint64) (map[uint64]models.PresenceDto, error) {
+	presences, err := s.store.GetMany(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var appIDs []uint64
+	for _, presence := range presences {
+		if presence.AppID != 0 {
+			appIDs = append(appIDs, presence.AppID)
+		}
+	}
+	apps, err := s.appRepo.FindByIDs(appIDs)
+	if err != nil {
+		return nil, err
+	}
+	appNames := make(map[uint64]string, len(apps))
+	for _, app := range apps {
+		appNames[app.AppId] = app.Name
+	}
+
+	res := make(map[uint64]models.PresenceDto, len(userIDs))
+	for _, id := range userIDs {
+		presence, ok := presences[id]
+		if !ok {
+			res[id] = models.PresenceDto{Status: models.PresenceOffline}
+			continue
+		}
+		res[id] = models.PresenceDto{
+			Status:   presence.Status,
+			AppID:    presence.AppID,
+			GameName: appNames[presence.AppID],
+		}
+	}
+	return res, nil
+}
+
+func (s *presenceService) SweepExpired() error {
+	return s.store.Sweep()
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"errors"
+	"steam-backend/models"
+	"steam-backend/repositories"
+
+	"gorm.io/gorm"
+)
+
+// ErrPrivacyRestricted 对方的隐私设置不允许当前用户查看
+var ErrPrivacyRestricted = errors.New("restricted by privacy settings")
+
+type PrivacyService interface {
+	GetSettings(userID uint64) (*models.PrivacySettingsDto, error)
+	UpdateSettings(userID uint64, req *models.UpdatePrivacyRequestDto) (*models.PrivacySettingsDto, error)
+	CanView(viewerID, ownerID uint64, field string) (bool, error)
+	FilterViewable(viewerID uint64, ownerIDs []uint64, field string) (map[uint64]bool, error)
+}
+
+type privacyService struct {
+	privacyRepo repositories.PrivacyRepository
+	friendRepo  repositories.FriendRepository
+}
+
+func NewPrivacyService(privacyRepo repositories.PrivacyRepository, friendRepo repositories.FriendRepository) PrivacyService {
+	return &privacyService{privacyRepo: privacyRepo, friendRepo: friendRepo}
+}
+
+func defaultPrivacySettings(userID uint64) *models.PrivacySettings {
+	return &models.PrivacySettings{
+		UserID:     userID,
+		Profile:    models.PrivacyPublic,
+		FriendList: models.PrivacyPublic,
+		Wishlist:   models.PrivacyPublic,
+	}
+}
+
+func (s *privacyService) load(userID uint64) (*models.PrivacySettings, error) {
+	settings, err := s.privacyRepo.FindByUserID(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return defaultPrivacySettings(userID), nil
+	}
+	return settings, err
+}
+
+func (s *privacyService) GetSettings(userID uint64) (*models.PrivacySettingsDto, error) {
+	settings, err := s.load(userID)
+	if err != nil {
+		return nil, err
+	}
+	return toPrivacySettingsDto(settings), nil
+}
+
+func (s *privacyService) UpdateSettings(userID uint64, req *models.UpdatePrivacyRequestDto) (*models.PrivacySettingsDto, error) {
+	settings, err := s.load(userID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Profile != nil {
+		settings.Profile = *req.Profile
+	}
+	if req.FriendList != nil {
+		settings.FriendList = *req.FriendList
+	}
+	if req.Wishlist != nil {
+		settings.Wishlist = *req.Wishlist
+	}
+	if err := s.privacyRepo.Save(settings); err != nil {
+		return nil, err
+	}
+	return toPrivacySettingsDto(settings), nil
+}
+
+// CanView viewerID为0表示未登录，本人总是可以查看自己的内容
+func (s *privacyService) CanView(viewerID, ownerID uint64, field string) (bool, error) {
+	if viewerID != 0 && viewerID == ownerID {
+		return true, nil
+	}
+	settings, err := s.load(ownerID)
+	if err != nil {
+		return false, err
+	}
+	return s.allowed(viewerID, ownerID, levelOf(settings, field))
+}
+
+// FilterViewable 批量判断，返回viewerID可以查看的ownerID集合
+func (s *privacyService) FilterViewable(viewerID uint64, ownerIDs []uint64, field string) (map[uint64]bool, error) {
+	rows, err := s.privacyRepo.FindByUserIDs(ownerIDs)
+	if err != nil {
+		return nil, err
+	}
+	levels := make(map[uint64]string, len(rows))
+	for i := range rows {
+		levels[rows[i].UserID] = levelOf(&rows[i], field)
+	}
+
+	res := make(map[uint64]bool, len(ownerIDs))
+	for _, ownerID := range ownerIDs {
+		if _, done := res[ownerID]; done {
+			continue
+		}
+		level, ok := levels[ownerID]
+		if !ok {
+			level = models.PrivacyPublic
+		}
+		if viewerID != 0 && viewerID == ownerID {
+			level = models.PrivacyPublic
+		}
+		allowed, err := s.allowed(viewerID, ownerID, level)
+		if err != nil {
+			return nil, err
+		}
+		res[ownerID] = allowed
+	}
+	return res, nil
+}
+
+func (s *privacyService) allowed(viewerID, ownerID uint64, level string) (bool, error) {
+	switch level {
+	case models.PrivacyPublic:
+		return true, nil
+	case models.PrivacyFriends:
+		if viewerID == 0 {
+			return false, nil
+		}
+		return s.friendRepo.IsFriends(viewerID, ownerID)
+	default:
+		return false, nil
+	}
+}
+
+func levelOf(settings *models.PrivacySettings, field string) string {
+	switch field {
+	case models.PrivacyFieldProfile:
+		return settings.Profile
+	case models.PrivacyFieldFriendList:
+		return settings.FriendList
+	case models.PrivacyFieldWishlist:
+		return settings.Wishlist
+	default:
+		return models.PrivacyPrivate
+	}
+}
+
+func toPrivacySettingsDto(settings *models.PrivacySettings) *models.PrivacySettingsDto {
+	return &models.PrivacySettingsDto{
+		Profile:    settings.Profile,
+		FriendList: settings.FriendList,
+		Wishlist:   settings.Wishlist,
+	}
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"steam-backend/config"
+	"steam-backend/models"
+	"steam-backend/repositories"
+	"steam-backend/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrCommentForbidden 只有留言作者和主页主人可以删除留言
+var ErrCommentForbidden = errors.New("no permission to delete this comment")
+
+type ProfileService interface {
+	GetProfile(viewerID, userID uint64) (*models.ProfileDto, error)
+	GetComments(viewerID, profileID uint64, page, pageSize int) (*models.PageDto, error)
+	PostComment(authorID, profileID uint64, content string) (*models.ProfileCommentDto, error)
+	DeleteComment(userID, commentID uint64) error
+}
+
+type profileService struct {
+	userRepo       repositories.UserRepository
+	friendRepo     repositories.FriendRepository
+	wishlistRepo   repositories.WishlistRepository
+	commentRepo    repositories.ProfileCommentRepository
+	privacyService PrivacyService
+	textFilter     TextFilterService
+	limiter        *utils.RateLimiter
+	rateLimit      int
+}
+
+func NewProfileService(userRepo repositories.UserRepository, friendRepo repositories.FriendRepository,
+	wishlistRepo repositories.WishlistRepository, commentRepo repositories.ProfileCommentRepository,
+	privacyService PrivacyService, textFilter TextFilterService, cfg config.Config) ProfileService {
+	return &profileService{
+		userRepo:       userRepo,
+		friendRepo:     friendRepo,
+		wishlistRepo:   wishlistRepo,
+		commentRepo:    commentRepo,
+		privacyService: privacyService,
+		textFilter:     textFilter,
+		limiter:        utils.NewRateLimiter(time.Minute),
+		rateLimit:      cfg.ProfileCommentRateLimit,
+	}
+}
+
+// GetProfile 基本信息总是可见，其余内容分别受对应的隐私设置控制
+func (s *profileService) GetProfile(viewerID, userID uint64) (*models.ProfileDto, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &models.ProfileDto{
+		UserDto:         toUserDto(user),
+		CommentsEnabled: !user.CommentsDisabled,
+	}
+
+	canView, err := s.privacyService.CanView(viewerID, userID, models.PrivacyFieldProfile)
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		res.Restricted = true
+		return res, nil
+	}
+	res.Bio = user.Bio
+	res.Country = user.Country
+	res.MemberSince = &user.CreatedAt
+
+	canView, err = s.privacyService.CanView(viewerID, userID, models.PrivacyFieldFriendList)
+	if err != nil {
+		return nil, err
+	}
+	if canView {
+		count, err := s.friendRepo.GetFriendCount(userID)
+		if err != nil {
+			return nil, err
+		}
+		res.FriendCount = &count
+	}
+
+	canView, err = s.privacyService.CanView(viewerID, userID, models.PrivacyFieldWishlist)
+	if err != nil {
+		return nil, err
+	}
+	if canView {
+		count, err := s.wishlistRepo.GetItemCount(userID)
+		if err != nil {
+			return nil, err
+		}
+		res.WishlistCount = &count
+	}
+	return res, nil
+}
+
+// GetComments 留言板和详细资料使用同一个隐私设置
+func (s *profileService) GetComments(viewerID, profileID uint64, page, pageSize int) (*models.PageDto, error) {
+	if _, err := s.userRepo.FindByID(profileID); err != nil {
+		return nil, err
+	}
+	canView, err := s.privacyService.CanView(viewerID, profileID, models.PrivacyFieldProfile)
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		return nil, ErrPrivacyRestricted
+	}
+
+	comments, total, err := s.commentRepo.PageComments(profileID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	authorIDs := make([]uint64, len(comments))
+	for i, comment := range comments {
+		authorIDs[i] = comment.AuthorID
+	}
+	authors, err := s.userRepo.FindByIDs(authorIDs)
+	if err != nil {
+		return nil, err
+	}
+	authorMap := make(map[uint64]*models.User, len(authors))
+	for i := range authors {
+		authorMap[authors[i].UserID] = &authors[i]
+	}
+
+	res := make([]models.ProfileCommentDto, 0, len(comments))
+	for i := range comments {
+		author, ok := authorMap[comments[i].AuthorID]
+		if !ok {
+			continue
+		}
+		res = append(res, toProfileCommentDto(&comments[i], author))
+	}
+
+	return &models.PageDto{
+		Total:     total,
+		PageIndex: page,
+		PageSize:  pageSize,
+		Data:      res,
+	}, nil
+}
+
+// PostComment 只有好友和主页主人自己可以留言，主人关闭留言板后不能再留言
+func (s *profileService) PostComment(authorID, profileID uint64, content string) (*models.ProfileCommentDto, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, errors.New("comment can not be blank")
+	}
+
+	owner, err := s.userRepo.FindByID(profileID)
+	if err != nil {
+		return nil, err
+	}
+	if owner.CommentsDisabled {
+		return nil, errors.New("comments are disabled")
+	}
+	if authorID != profileID {
+		isFriend, err := s.friendRepo.IsFriends(authorID, profileID)
+		if err != nil {
+			return nil, err
+		}
+		if !isFriend {
+			return nil, errors.New("only friends can comment")
+		}
+	}
+	if allowed, retryAfter := s.limiter.Allow(strconv.FormatUint(authorID, 10), s.rateLimit); !allowed {
+		return nil, &RateLimitedError{RetryAfter: retryAfter}
+	}
+
+	content, flagged, err := s.textFilter.Check(TextFieldComment, content)
+	if err != nil {
+		return nil, err
+	}
+	author, err := s.userRepo.FindByID(authorID)
+	if err != nil {
+		return nil, err
+	}
+	comment := models.ProfileComment{
+		ProfileID: profileID,
+		AuthorID:  authorID,
+		Content:   content,
+	}
+	if err := s.commentRepo.CreateComment(&comment); err != nil {
+		return nil, err
+	}
+	if flagged {
+		if err := s.textFilter.Flag(models.ReportTargetComment, comment.ID, authorID); err != nil {
+			log.Printf("flag comment %d failed: %v", comment.ID, err)
+		}
+	}
+
+	res := toProfileCommentDto(&comment, author)
+	return &res, nil
+}
+
+func (s *profileService) DeleteComment(userID, commentID uint64) error {
+	comment, err := s.commentRepo.FindComment(commentID)
+	if err != nil {
+		return err
+	}
+	if comment.ProfileID != userID && comment.AuthorID != userID {
+		return ErrCommentForbidden
+	}
+	return s.commentRepo.DeleteComment(commentID)
+}
+
+func toProfileCommentDto(comment *models.ProfileComment, author *models.User) models.ProfileCommentDto {
+	return models.ProfileCommentDto{
+		ID:        comment.ID,
+		Author:    toUserDto(author),
+		Content:   comment.Content,
+		CreatedAt: comment.CreatedAt,
+	}
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"log"
+	"math"
+	"sort"
+	"steam-backend/config"
+	"steam-backend/models"
+	"steam-backend/repositories"
+)
+
+const (
+	//用于召回候选游戏的偏好标签数
+	recommendTopTags = 5
+	//每次召回并打分的候选游戏数
+	recommendCandidatePool = 200
+	//标签匹配度与好评率在总分中的权重
+	recommendTagWeight     = 0.7
+	recommendQualityWeight = 0.3
+)
+
+// similarityMinSupportFloor 共同加入的用户少于这个数时，相关游戏可能暴露个别用户的私密愿望单，
+// 配置的SimilarityMinSupport不能低于该值
+const similarityMinSupportFloor = 3
+
+type RecommendationService interface {
+	GetRecommendations(userID uint64, limit int) ([]models.AppDto, error)
+	GetAlsoWishlisted(appID uint64, limit int) ([]models.AppDto, error)
+	RebuildSimilarities() error
+}
+
+type recommendationService struct {
+	appRepo        repositories.AppRepository
+	wishlistRepo   repositories.WishlistRepository
+	similarityRepo repositories.SimilarityRepository
+	minSupport     int
+	maxRelated     int
+}
+
+func NewRecommendationService(appRepo repositories.AppRepository, wishlistRepo repositories.WishlistRepository,
+	similarityRepo repositories.SimilarityRepository, cfg config.Config) RecommendationService {
+	minSupport := cfg.SimilarityMinSupport
+	if minSupport < similarityMinSupportFloor {
+		log.Printf("SIMILARITY_MIN_SUPPORT %d is below %d, using %d", minSupport, similarityMinSupportFloor,
+			similarityMinSupportFloor)
+		minSupport = similarityMinSupportFloor
+	}
+	return &recommendationService{
+		appRepo:        appRepo,
+		wishlistRepo:   wishlistRepo,
+		similarityRepo: similarityRepo,
+		minSupport:     minSupport,
+		maxRelated:     cfg.SimilarityMaxRelated,
+	}
+}
+
+// GetRecommendations userID为0或愿望单中没有带标签的游戏时返回按好评率排序的全站推荐
+func (s *recommendationService) GetRecommendations(userID uint64, limit int) ([]models.AppDto, error) {
+	if userID == 0 {
+		return s.globalRecommendations(limit)
+	}
+	items, err := s.wishlistRepo.GetWishlist(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return s.globalRecommendations(limit)
+	}
+
+	wishlistIDs := make([]uint64, len(items))
+	for i, item := range items {
+		wishlistIDs[i] = item.AppID
+	}
+	wishlistApps, err := s.appRepo.FindByIDs(wishlistIDs)
+	if err != nil {
+		return nil, err
+	}
+	affinity := tagAffinity(wishlistApps)
+	if len(affinity) == 0 {
+		return s.globalRecommendations(limit)
+	}
+
+	candidates, err := s.appRepo.FindCandidates(topTags(affinity, recommendTopTags), wishlistIDs, recommendCandidatePool)
+	if err != nil {
+		return nil, err
+	}
+	norm := 0.0
+	for _, weight := range affinity {
+		norm += weight * weight
+	}
+	norm = math.Sqrt(norm)
+
+	type scoredApp struct {
+		app   *models.App
+		score float64
+	}
+	scored := make([]scoredApp, 0, len(candidates))
+	for i := range candidates {
+		match := tagMatch(models.SplitTags(candidates[i].Tags), affinity, norm)
+		if match == 0 {
+			continue
+		}
+		quality := float64(candidates[i].PositiveRate) / 100
+		scored = append(scored, scoredApp{
+			app:   &candidates[i],
+			score: recommendTagWeight*match + recommendQualityWeight*quality,
+		})
+	}
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].score > scored[j].score
+	})
+
+	res := make([]models.AppDto, 0, limit)
+	exclude := wishlistIDs
+	for i := 0; i < len(scored) && len(res) < limit; i++ {
+		res = append(res, toAppDto(scored[i].app))
+		exclude = append(exclude, scored[i].app.AppId)
+	}
+
+	//匹配的游戏不够时用全站推荐补齐
+	if len(res) < limit {
+		fill, err := s.appRepo.FindCandidates(nil, exclude, limit-len(res))
+		if err != nil {
+			return nil, err
+		}
+		for i := range fill {
+			res = append(res, toAppDto(&fill[i]))
+		}
+	}
+	return res, nil
+}
+
+// GetAlsoWishlisted 读取后台任务算好的相似度表，已下架的游戏跳过；
+// 每个游戏最多只保存maxRelated个相关游戏，limit超过时按maxRelated处理
+func (s *recommendationService) GetAlsoWishlisted(appID uint64, limit int) ([]models.AppDto, error) {
+	if limit > s.maxRelated {
+		limit = s.maxRelated
+	}
+	if _, err := s.appRepo.FindByID(appID); err != nil {
+		return nil, err
+	}
+	related, err := s.similarityRepo.FindRelated(appID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	appIDs := make([]uint64, len(related))
+	for i, row := range related {
+		appIDs[i] = row.RelatedAppID
+	}
+	apps, err := s.appRepo.FindByIDs(appIDs)
+	if err != nil {
+		return nil, err
+	}
+	appMap := make(map[uint64]*models.App, len(apps))
+	for i := range apps {
+		appMap[apps[i].AppId] = &apps[i]
+	}
+
+	res := make([]models.AppDto, 0, len(related))
+	for _, id := range appIDs {
+		if app, ok := appMap[id]; ok {
+			res = append(res, toAppDto(app))
+		}
+	}
+	return res, nil
+}
+
+// RebuildSimilarities 得分为共同加入人数除以两个游戏各自加入人数乘积的平方根，
+// 每个游戏只保留得分最高的maxRelated个相关游戏
+func (s *recommendationService) RebuildSimilarities() error {
+	counts, err := s.similarityRepo.CountWishlistsByApp()
+	if err != nil {
+		return err
+	}
+	countMap := make(map[uint64]int64, len(counts))
+	for _, c := range counts {
+		countMap[c.AppID] = c.Count
+	}
+
+	pairs, err := s.similarityRepo.FindCoOccurrences(s.minSupport)
+	if err != nil {
+		return err
+	}
+	byApp := make(map[uint64][]models.AppSimilarity)
+	for _, pair := range pairs {
+		//两次查询之间愿望单可能被清空，计数为0时得分会是NaN或+Inf
+		appCount, relatedCount := countMap[pair.AppID], countMap[pair.RelatedAppID]
+		if appCount == 0 || relatedCount == 0 {
+			continue
+		}
+		pair.Score = float64(pair.Support) / math.Sqrt(float64(appCount*relatedCount))
+		byApp[pair.AppID] = append(byApp[pair.AppID], pair)
+	}
+
+	rows := make([]models.AppSimilarity, 0, len(pairs))
+	for _, related := range byApp {
+		sort.Slice(related, func(i, j int) bool {
+			if related[i].Score != related[j].Score {
+				return related[i].Score > related[j].Score
+			}
+			return related[i].Support > related[j].Support
+		})
+		if len(related) > s.maxRelated {
+			related = related[:s.maxRelated]
+		}
+		rows = append(rows, related...)
+	}
+
+	if err := s.similarityRepo.ReplaceAll(rows); err != nil {
+		return err
+	}
+	log.Printf("rebuilt app similarities: %d apps, %d rows", len(byApp), len(rows))
+	return nil
+}
+
+func (s *recommendationService) globalRecommendations(limit int) ([]models.AppDto, error) {
+	apps, err := s.appRepo.FindRecommendations(limit)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]models.AppDto, len(apps))
+	for i := range apps {
+		res[i] = toAppDto(&apps[i])
+	}
+	return res, nil
+}
+
+// tagAffinity 每个标签的权重为愿望单中带有该标签的游戏占比
+func tagAffinity(apps []models.App) map[string]float64 {
+	res := make(map[string]float64)
+	tagged := 0
+	for _, app := range apps {
+		tags := models.SplitTags(app.Tags)
+		if len(tags) == 0 {
+			continue
+		}
+		tagged++
+		for _, tag := range tags {
+			res[tag]++
+		}
+	}
+	for tag := range res {
+		res[tag] /= float64(tagged)
+	}
+	return res
+}
+
+// topTags 权重相同时按标签名排序，保证结果稳定
+func topTags(affinity map[string]float64, n int) []string {
+	tags := make([]string, 0, len(affinity))
+	for tag := range affinity {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if affinity[tags[i]] != affinity[tags[j]] {
+			return affinity[tags[i]] > affinity[tags[j]]
+		}
+		return tags[i] < tags[j]
+	})
+	if len(tags) > n {
+		tags = tags[:n]
+	}
+	return tags
+}
+
+// tagMatch 游戏标签与用户偏好的余弦相似度，取值0到1，标签多的游戏不会因此占优
+func tagMatch(tags []string, affinity map[string]float64, norm float64) float64 {
+	if len(tags) == 0 || norm == 0 {
+		return 0
+	}
+	sum := 0.0
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		sum += affinity[tag]
+	}
+	return sum / (math.Sqrt(float64(len(seen))) * norm)
+}
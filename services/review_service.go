@@ -0,0 +1,179 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"steam-backend/models"
+	"steam-backend/repositories"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type ReviewService interface {
+	CreateReview(userID, appID uint64, recommended bool, content string) (*models.ReviewDto, error)
+	UpdateReview(userID, appID uint64, recommended bool, content string) (*models.ReviewDto, error)
+	DeleteReview(userID, appID uint64) error
+	GetReviews(appID uint64, sort string, page, pageSize int) (*models.PageDto, error)
+	MarkHelpful(userID, reviewID uint64, helpful bool) error
+}
+
+type reviewService struct {
+	reviewRepo repositories.ReviewRepository
+	appRepo    repositories.AppRepository
+	userRepo   repositories.UserRepository
+	textFilter TextFilterService
+}
+
+func NewReviewService(reviewRepo repositories.ReviewRepository, appRepo repositories.AppRepository,
+	userRepo repositories.UserRepository, textFilter TextFilterService) ReviewService {
+	return &reviewService{
+		reviewRepo: reviewRepo,
+		appRepo:    appRepo,
+		userRepo:   userRepo,
+		textFilter: textFilter,
+	}
+}
+
+func (s *reviewService) CreateReview(userID, appID uint64, recommended bool, content string) (*models.ReviewDto, error) {
+	if _, err := s.appRepo.FindByID(appID); err != nil {
+		return nil, err
+	}
+	_, err := s.reviewRepo.FindUserReview(appID, userID)
+	if err == nil {
+		return nil, errors.New("already reviewed this app")
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	content, flagged, err := s.textFilter.Check(TextFieldReview, strings.TrimSpace(content))
+	if err != nil {
+		return nil, err
+	}
+
+	review := &models.Review{
+		AppID:       appID,
+		UserID:      userID,
+		Recommended: recommended,
+		Content:     content,
+	}
+	if err := s.reviewRepo.CreateReview(review); err != nil {
+		return nil, err
+	}
+	s.flagReview(review, flagged)
+	return s.toReviewDto(review)
+}
+
+func (s *reviewService) UpdateReview(userID, appID uint64, recommended bool, content string) (*models.ReviewDto, error) {
+	review, err := s.reviewRepo.FindUserReview(appID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	content, flagged, err := s.textFilter.Check(TextFieldReview, strings.TrimSpace(content))
+	if err != nil {
+		return nil, err
+	}
+
+	review.Recommended = recommended
+	review.Content = content
+	if err := s.reviewRepo.UpdateReview(review); err != nil {
+		return nil, err
+	}
+	s.flagReview(review, flagged)
+	return s.toReviewDto(review)
+}
+
+// flagReview 评测已经保存，送审失败只记录日志
+func (s *reviewService) flagReview(review *models.Review, flagged bool) {
+	if !flagged {
+		return
+	}
+	if err := s.textFilter.Flag(models.ReportTargetReview, review.ID, review.UserID); err != nil {
+		log.Printf("flag review %d failed: %v", review.ID, err)
+	}
+}
+
+func (s *reviewService) DeleteReview(userID, appID uint64) error {
+	review, err := s.reviewRepo.FindUserReview(appID, userID)
+	if err != nil {
+		return err
+	}
+	return s.reviewRepo.DeleteReview(review)
+}
+
+func (s *reviewService) GetReviews(appID uint64, sort string, page, pageSize int) (*models.PageDto, error) {
+	if _, err := s.appRepo.FindByID(appID); err != nil {
+		return nil, err
+	}
+	reviews, total, err := s.reviewRepo.PageReviews(appID, sort, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]uint64, len(reviews))
+	for i, review := range reviews {
+		userIDs[i] = review.UserID
+	}
+	users, err := s.userRepo.FindByIDs(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	userMap := make(map[uint64]*models.User, len(users))
+	for i := range users {
+		userMap[users[i].UserID] = &users[i]
+	}
+
+	res := make([]models.ReviewDto, 0, len(reviews))
+	for i := range reviews {
+		user, ok := userMap[reviews[i].UserID]
+		if !ok {
+			continue
+		}
+		res = append(res, toReviewDto(&reviews[i], user))
+	}
+
+	return &models.PageDto{
+		Total:     total,
+		PageIndex: page,
+		PageSize:  pageSize,
+		Data:      res,
+	}, nil
+}
+
+// MarkHelpful 不能给自己的评测投票
+func (s *reviewService) MarkHelpful(userID, reviewID uint64, helpful bool) error {
+	review, err := s.reviewRepo.FindReview(reviewID)
+	if err != nil {
+		return err
+	}
+	if review.UserID == userID {
+		return errors.New("can not vote on own review")
+	}
+	if helpful {
+		return s.reviewRepo.AddHelpfulVote(reviewID, userID)
+	}
+	return s.reviewRepo.RemoveHelpfulVote(reviewID, userID)
+}
+
+func (s *reviewService) toReviewDto(review *models.Review) (*models.ReviewDto, error) {
+	user, err := s.userRepo.FindByID(review.UserID)
+	if err != nil {
+		return nil, err
+	}
+	res := toReviewDto(review, user)
+	return &res, nil
+}
+
+func toReviewDto(review *models.Review, author *models.User) models.ReviewDto {
+	return models.ReviewDto{
+		ID:           review.ID,
+		AppID:        review.AppID,
+		Author:       toUserDto(author),
+		Recommended:  review.Recommended,
+		Content:      review.Content,
+		HelpfulCount: review.HelpfulCount,
+		CreatedAt:    review.CreatedAt,
+		UpdatedAt:    review.UpdatedAt,
+	}
+}
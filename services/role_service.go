@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"steam-backend/models"
+	"steam-backend/repositories"
+)
+
+type RoleService interface {
+	GrantRole(actorID, targetID uint64, role string) error
+	RevokeRole(actorID, targetID uint64) error
+	GetAuditLogs(targetID uint64, page, pageSize int) (*models.PageDto, error)
+	BootstrapAdmins(userIDs []uint64) error
+	CurrentRole(userID uint64) (string, error)
+}
+
+type roleService struct {
+	roleRepo repositories.RoleRepository
+}
+
+func NewRoleService(repo repositories.RoleRepository) RoleService {
+	return &roleService{roleRepo: repo}
+}
+
+func (s *roleService) GrantRole(actorID, targetID uint64, role string) error {
+	if !models.IsValidRole(role) || role == models.RoleUser {
+		return errors.New("invalid role")
+	}
+	if actorID == targetID {
+		return errors.New("can not change own role")
+	}
+	return s.roleRepo.ChangeRole(actorID, targetID, "grant", role)
+}
+
+// RevokeRole 撤销后用户回到普通user角色
+func (s *roleService) RevokeRole(actorID, targetID uint64) error {
+	if actorID == targetID {
+		return errors.New("can not change own role")
+	}
+	return s.roleRepo.ChangeRole(actorID, targetID, "revoke", models.RoleUser)
+}
+
+func (s *roleService) GetAuditLogs(targetID uint64, page, pageSize int) (*models.PageDto, error) {
+	logs, total, err := s.roleRepo.GetAuditLogs(targetID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &models.PageDto{
+		Total:     total,
+		PageIndex: page,
+		PageSize:  pageSize,
+		Data:      logs,
+	}, nil
+}
+
+// BootstrapAdmins 启动时把ADMIN_USER_IDS中的用户提升为admin，保证至少有一个管理员
+func (s *roleService) BootstrapAdmins(userIDs []uint64) error {
+	var errs []error
+	for _, id := range userIDs {
+		if err := s.roleRepo.ChangeRole(0, id, "grant", models.RoleAdmin); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CurrentRole 返回数据库中的当前角色，供RequireRole使用，使授予和撤销立即生效
+func (s *roleService) CurrentRole(userID uint64) (string, error) {
+	role, err := s.roleRepo.FindRole(userID)
+	if err != nil {
+		return "", err
+	}
+	if role == "" {
+		role = models.RoleUser
+	}
+	return role, nil
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"errors"
+	"steam-backend/models"
+	"steam-backend/repositories"
+	"steam-backend/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SessionService 供认证中间件使用，检查token签发之后发生的变化
+type SessionService interface {
+	ActiveBan(userID uint64) (*models.UserBan, error)
+	TokenRevoked(claims *utils.Claims) (bool, error)
+}
+
+type sessionService struct {
+	moderation ModerationService
+	userRepo   repositories.UserRepository
+	oauthRepo  repositories.OAuthRepository
+}
+
+func NewSessionService(moderation ModerationService, userRepo repositories.UserRepository,
+	oauthRepo repositories.OAuthRepository) SessionService {
+	return &sessionService{
+		moderation: moderation,
+		userRepo:   userRepo,
+		oauthRepo:  oauthRepo,
+	}
+}
+
+func (s *sessionService) ActiveBan(userID uint64) (*models.UserBan, error) {
+	return s.moderation.ActiveBan(userID)
+}
+
+// TokenRevoked 用户已被删除或修改过密码时，之前签发的token失效；
+// 第三方应用被删除后，为其签发的token也立即失效
+func (s *sessionService) TokenRevoked(claims *utils.Claims) (bool, error) {
+	user, err := s.userRepo.FindByID(claims.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	//iat只精确到秒，改密码的同一秒内重新登录拿到的token不能被判为失效
+	if user.PasswordChangedAt != nil && claims.IssuedAt != nil &&
+		claims.IssuedAt.Time.Before(user.PasswordChangedAt.Truncate(time.Second)) {
+		return true, nil
+	}
+
+	if claims.ClientID == "" {
+		return false, nil
+	}
+	_, err = s.oauthRepo.FindClient(claims.ClientID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return true, nil
+	}
+	return false, err
+}
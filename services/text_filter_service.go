@@ -0,0 +1,125 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"steam-backend/config"
+	"steam-backend/models"
+	"steam-backend/repositories"
+	"steam-backend/textfilter"
+)
+
+// ErrTextRejected 文本命中过滤规则且该字段的处理方式为reject
+var ErrTextRejected = errors.New("text contains prohibited content")
+
+// 需要过滤的文本字段，也是TEXT_FILTER_MODES中的key
+const (
+	TextFieldNickName  = "nickname"
+	TextFieldBio       = "bio"
+	TextFieldReview    = "review"
+	TextFieldComment   = "comment"
+	TextFieldMessage   = "message"
+	TextFieldCommunity = "community"
+	TextFieldCurator   = "curator"
+)
+
+// flaggableFields 只有能在举报系统中定位到的内容才能送审
+var flaggableFields = map[string]bool{
+	TextFieldNickName: true,
+	TextFieldBio:      true,
+	TextFieldReview:   true,
+	TextFieldComment:  true,
+}
+
+type TextFilterService interface {
+	// Check 返回实际保存的文本和是否需要送审，需要送审时调用方保存内容后再调用Flag
+	Check(field, text string) (string, bool, error)
+	// Validate 命中规则即拒绝，不看字段配置，用于用户名、群组名等需要唯一、不能打码的文本
+	Validate(text string) error
+	Flag(targetType string, targetID, targetUserID uint64) error
+}
+
+type textFilterService struct {
+	filter         *textfilter.Filter
+	moderationRepo repositories.ModerationRepository
+	defaultMode    string
+	modes          map[string]string
+}
+
+// NewTextFilterService 无效的处理方式回退为默认处理方式，不能送审的字段配置为flag时改为mask
+func NewTextFilterService(filter *textfilter.Filter, moderationRepo repositories.ModerationRepository,
+	cfg config.Config) TextFilterService {
+	defaultMode := cfg.TextFilterDefaultMode
+	if !textfilter.IsValidMode(defaultMode) {
+		log.Printf("invalid text filter mode %q, using %s", defaultMode, textfilter.ModeReject)
+		defaultMode = textfilter.ModeReject
+	}
+	modes := make(map[string]string, len(cfg.TextFilterModes))
+	for field, mode := range cfg.TextFilterModes {
+		if !textfilter.IsValidMode(mode) {
+			log.Printf("invalid text filter mode %q for %s, using %s", mode, field, defaultMode)
+			continue
+		}
+		modes[field] = mode
+	}
+
+	return &textFilterService{
+		filter:         filter,
+		moderationRepo: moderationRepo,
+		defaultMode:    defaultMode,
+		modes:          modes,
+	}
+}
+
+func (s *textFilterService) modeOf(field string) string {
+	mode, ok := s.modes[field]
+	if !ok {
+		mode = s.defaultMode
+	}
+	if mode == textfilter.ModeFlag && !flaggableFields[field] {
+		return textfilter.ModeMask
+	}
+	return mode
+}
+
+// Check mask模式下刷屏内容无法打码，仍然拒绝
+func (s *textFilterService) Check(field, text string) (string, bool, error) {
+	res := s.filter.Check(text)
+	if res.Clean() {
+		return text, false, nil
+	}
+
+	switch s.modeOf(field) {
+	case textfilter.ModeMask:
+		if res.Repetitive {
+			return "", false, ErrTextRejected
+		}
+		return s.filter.Mask(text), false, nil
+	case textfilter.ModeFlag:
+		return text, true, nil
+	}
+	return "", false, ErrTextRejected
+}
+
+func (s *textFilterService) Validate(text string) error {
+	if !s.filter.Check(text).Clean() {
+		return ErrTextRejected
+	}
+	return nil
+}
+
+// Flag 以系统身份提交举报，同一内容已有未处理的自动举报时不再重复提交
+func (s *textFilterService) Flag(targetType string, targetID, targetUserID uint64) error {
+	exists, err := s.moderationRepo.HasOpenReport(0, targetType, targetID)
+	if err != nil || exists {
+		return err
+	}
+	return s.moderationRepo.CreateReport(&models.Report{
+		TargetType:   targetType,
+		TargetID:     targetID,
+		TargetUserID: targetUserID,
+		Reason:       models.ReportReasonFiltered,
+		Detail:       "flagged by text filter",
+		Status:       models.ReportStatusOpen,
+	})
+}
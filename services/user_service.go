@@ -2,10 +2,15 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"log"
+	"regexp"
 	"steam-backend/config"
 	"steam-backend/models"
 	"steam-backend/repositories"
 	"steam-backend/utils"
+	"strings"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -13,25 +18,48 @@ import (
 
 type UserService interface {
 	Register(userDTO *models.JoinRequestDto) (*models.User, error)
-	Login(loginDTO *models.LoginRequestDto) (string, *models.User, error)
+	Login(loginDTO *models.LoginRequestDto, ip string) (string, *models.User, error)
 	GetUserInfo(userID uint64) (*models.User, error)
 	ChechUserNameAvailable(username string) (bool, error)
-	SearchUsers(keyword string) ([]models.User, error)
+	SearchUsers(keyword string, viewerID uint64) ([]models.User, error)
+	UnlockAccount(username, ip string)
+	UpdateProfile(userID uint64, req *models.UpdateProfileRequestDto) (*models.User, error)
+	ChangeUsername(userID uint64, newName string) (*models.User, error)
+	GetUsernameHistory(userID uint64) ([]models.UsernameHistory, error)
+	ChangePassword(userID uint64, oldPassword, newPassword string) error
 }
 
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,20}$`)
+
 type userService struct {
-	userRepo repositories.UserRepository
-	config   config.Config
+	userRepo        repositories.UserRepository
+	activityService ActivityService
+	textFilter      TextFilterService
+	moderation      ModerationService
+	config          config.Config
+	loginGuard      *LoginGuard
 }
 
-func NewUserService(repo repositories.UserRepository, conf config.Config) UserService {
+func NewUserService(repo repositories.UserRepository, activityService ActivityService, textFilter TextFilterService,
+	moderation ModerationService, conf config.Config) UserService {
 	return &userService{
-		userRepo: repo,
-		config:   conf,
+		userRepo:        repo,
+		activityService: activityService,
+		textFilter:      textFilter,
+		moderation:      moderation,
+		config:          conf,
+		loginGuard: NewLoginGuard(conf.LoginMaxFailures, conf.LoginMaxFailuresPerIP,
+			conf.LoginBackoffBase, conf.LoginLockoutDuration),
 	}
 }
 
 func (s *userService) Register(joinRequestDTO *models.JoinRequestDto) (*models.User, error) {
+	if !usernamePattern.MatchString(joinRequestDTO.UserName) {
+		return nil, errors.New("userName must be 3-20 letters, digits or underscores")
+	}
+	if err := s.textFilter.Validate(joinRequestDTO.UserName); err != nil {
+		return nil, err
+	}
 	userName, _ := s.userRepo.FindByUsername(joinRequestDTO.UserName)
 	if userName != nil {
 		return nil, errors.New("userName been used")
@@ -46,8 +74,9 @@ func (s *userService) Register(joinRequestDTO *models.JoinRequestDto) (*models.U
 	}
 	newUser := &models.User{
 		Email:    joinRequestDTO.Email,
-		UserName: hashPassword,
-		PassWord: joinRequestDTO.PassWord,
+		UserName: joinRequestDTO.UserName,
+		NickName: joinRequestDTO.UserName,
+		PassWord: hashPassword,
 	}
 	if err := s.userRepo.Create(newUser); err != nil {
 		return nil, err
@@ -55,18 +84,33 @@ func (s *userService) Register(joinRequestDTO *models.JoinRequestDto) (*models.U
 	return newUser, nil
 }
 
-func (s *userService) Login(loginDTO *models.LoginRequestDto) (string, *models.User, error) {
+func (s *userService) Login(loginDTO *models.LoginRequestDto, ip string) (string, *models.User, error) {
+	if err := s.loginGuard.Check(loginDTO.UserName, ip); err != nil {
+		return "", nil, err
+	}
+
 	user, _ := s.userRepo.FindByUsername(loginDTO.UserName)
 	if user == nil {
+		s.loginGuard.RecordFailure(loginDTO.UserName, ip)
 		return "", nil, errors.New("username is not exists")
 	}
 
 	compare := CheckPassword(loginDTO.Password, user.PassWord)
 	if !compare {
+		s.loginGuard.RecordFailure(loginDTO.UserName, ip)
 		return "", nil, errors.New("password is incorrect")
 	}
+	s.loginGuard.RecordSuccess(loginDTO.UserName, ip)
 
-	token, err := utils.GenerateToken(user.UserID, s.config.JWTSecret)
+	//与AuthMiddleware一致，查询失败时放行
+	ban, err := s.moderation.ActiveBan(user.UserID)
+	if err != nil {
+		log.Printf("check ban of user %d failed: %v", user.UserID, err)
+	} else if ban != nil {
+		return "", nil, &AccountBannedError{Ban: ban}
+	}
+
+	token, err := utils.GenerateToken(user.UserID, user.Role, s.config.JWTSecret)
 	if err != nil {
 		return "", nil, err
 	}
@@ -85,11 +129,133 @@ func (s *userService) ChechUserNameAvailable(username string) (bool, error) {
 		}
 		return false, err
 	}
-	return false, errors.New("userName exists")
+	return false, nil
+}
+
+func (s *userService) SearchUsers(keyword string, viewerID uint64) ([]models.User, error) {
+	return s.userRepo.SearchUsers(keyword, 20, viewerID)
+}
+
+func (s *userService) UnlockAccount(username, ip string) {
+	s.loginGuard.Unlock(username, ip)
 }
 
-func (s *userService) SearchUsers(keyword string) ([]models.User, error) {
-	return s.userRepo.SearchUsers(keyword, 20)
+func (s *userService) UpdateProfile(userID uint64, req *models.UpdateProfileRequestDto) (*models.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	//只有公开展示的资料有变化时才产生动态，评论开关等设置不算
+	before := *user
+	var flagged []string
+	if req.NickName != nil {
+		nickName := strings.TrimSpace(*req.NickName)
+		if nickName == "" {
+			return nil, errors.New("nickName can not be blank")
+		}
+		nickName, flag, err := s.textFilter.Check(TextFieldNickName, nickName)
+		if err != nil {
+			return nil, err
+		}
+		if flag {
+			flagged = append(flagged, models.ReportTargetNickname)
+		}
+		user.NickName = nickName
+	}
+	if req.Bio != nil {
+		bio, flag, err := s.textFilter.Check(TextFieldBio, strings.TrimSpace(*req.Bio))
+		if err != nil {
+			return nil, err
+		}
+		if flag {
+			flagged = append(flagged, models.ReportTargetProfile)
+		}
+		user.Bio = bio
+	}
+	if req.Country != nil {
+		user.Country = strings.ToUpper(*req.Country)
+	}
+	if req.CommentsEnabled != nil {
+		user.CommentsDisabled = !*req.CommentsEnabled
+	}
+
+	err = s.userRepo.UpdateColumns(user, "nickName", "bio", "country", "commentsDisabled")
+	if err != nil {
+		return nil, err
+	}
+	for _, targetType := range flagged {
+		if err := s.textFilter.Flag(targetType, userID, userID); err != nil {
+			log.Printf("flag %s of user %d failed: %v", targetType, userID, err)
+		}
+	}
+	if user.NickName != before.NickName || user.Bio != before.Bio || user.Country != before.Country {
+		s.activityService.Record(userID, models.ActivityProfileUpdated, 0)
+	}
+	return user, nil
+}
+
+func (s *userService) ChangeUsername(userID uint64, newName string) (*models.User, error) {
+	if !usernamePattern.MatchString(newName) {
+		return nil, errors.New("userName must be 3-20 letters, digits or underscores")
+	}
+	if err := s.textFilter.Validate(newName); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.UserName == newName {
+		return nil, errors.New("userName not changed")
+	}
+	if user.UserNameChangedAt != nil {
+		if next := user.UserNameChangedAt.Add(s.config.UsernameChangeCooldown); time.Now().Before(next) {
+			return nil, fmt.Errorf("userName can be changed again after %s", next.Format(time.DateTime))
+		}
+	}
+
+	available, err := s.ChechUserNameAvailable(newName)
+	if err != nil {
+		return nil, err
+	}
+	if !available {
+		return nil, errors.New("userName been used")
+	}
+
+	if err := s.userRepo.ChangeUsername(user, newName); err != nil {
+		return nil, err
+	}
+	s.activityService.Record(userID, models.ActivityProfileUpdated, 0)
+	return user, nil
+}
+
+func (s *userService) GetUsernameHistory(userID uint64) ([]models.UsernameHistory, error) {
+	return s.userRepo.GetUsernameHistory(userID)
+}
+
+func (s *userService) ChangePassword(userID uint64, oldPassword, newPassword string) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if !CheckPassword(oldPassword, user.PassWord) {
+		return errors.New("password is incorrect")
+	}
+	if oldPassword == newPassword {
+		return errors.New("new password must differ from the old one")
+	}
+
+	hashPassword, err := HashPassword(newPassword)
+	if err != nil {
+		return errors.New("hashpassword failed")
+	}
+	now := time.Now()
+	user.PassWord = hashPassword
+	user.PasswordChangedAt = &now
+	return s.userRepo.UpdateColumns(user, "passWord", "passwordChangedAt")
 }
 
 func HashPassword(password string) (string, error) {
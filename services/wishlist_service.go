@@ -10,19 +10,25 @@ type WishlistService interface {
 	RemoveFromWishlist(userID, appID uint64) error
 	GetWishlistSize(userID uint64) (int64, error)
 	GetWishlist(userID uint64) ([]models.WishlistItemDto, error)
+	GetUserWishlist(viewerID, userID uint64) ([]models.WishlistItemDto, error)
 	IsInWishlist(userID, appID uint64) (bool, error)
 	SortWishlist(userID uint64, sortItems []models.SortItem) error
 }
 
 type wishlistService struct {
-	wishlistRepo repositories.WishlistRepository
-	appRepo      repositories.AppRepository
+	wishlistRepo    repositories.WishlistRepository
+	appRepo         repositories.AppRepository
+	activityService ActivityService
+	privacyService  PrivacyService
 }
 
-func NewWishlistService(wishrepo repositories.WishlistRepository, apprepo repositories.AppRepository) WishlistService {
+func NewWishlistService(wishrepo repositories.WishlistRepository, apprepo repositories.AppRepository,
+	activityService ActivityService, privacyService PrivacyService) WishlistService {
 	return &wishlistService{
-		wishlistRepo: wishrepo,
-		appRepo:      apprepo,
+		wishlistRepo:    wishrepo,
+		appRepo:         apprepo,
+		activityService: activityService,
+		privacyService:  privacyService,
 	}
 }
 
@@ -32,7 +38,19 @@ func (s *wishlistService) AddToWishlist(userID, appID uint64) error {
 		return err
 	}
 
-	return s.wishlistRepo.AddItem(userID, appID)
+	//重复添加不产生新的动态
+	exists, err := s.wishlistRepo.IsInWishList(userID, appID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	if err := s.wishlistRepo.AddItem(userID, appID); err != nil {
+		return err
+	}
+	s.activityService.Record(userID, models.ActivityWishlistAdded, appID)
+	return nil
 }
 
 func (s *wishlistService) RemoveFromWishlist(userID, appID uint64) error {
@@ -57,6 +75,18 @@ func (s *wishlistService) GetWishlist(userID uint64) ([]models.WishlistItemDto,
 	return wishlist_dto, nil
 }
 
+// GetUserWishlist 查看他人的愿望单，受对方的隐私设置控制
+func (s *wishlistService) GetUserWishlist(viewerID, userID uint64) ([]models.WishlistItemDto, error) {
+	canView, err := s.privacyService.CanView(viewerID, userID, models.PrivacyFieldWishlist)
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		return nil, ErrPrivacyRestricted
+	}
+	return s.GetWishlist(userID)
+}
+
 func (s *wishlistService) IsInWishlist(userID, appID uint64) (bool, error) {
 	return s.wishlistRepo.IsInWishList(userID, appID)
 }
@@ -0,0 +1,10 @@
+package storage
+
+import "io"
+
+// BlobStore 存放用户上传的二进制文件，返回可直接对外访问的url
+// 目前只有本地文件系统实现，之后可以换成对象存储
+type BlobStore interface {
+	Put(key string, r io.Reader, contentType string) (string, error)
+	Delete(key string) error
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type localBlobStore struct {
+	root    string
+	baseURL string
+}
+
+// NewLocalBlobStore 文件写到root目录下，baseURL需要与路由中挂载静态目录的前缀一致
+func NewLocalBlobStore(root, baseURL string) (BlobStore, error) {
+	if root == "" {
+		return nil, errors.New("blob store root is empty")
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &localBlobStore{root: root, baseURL: strings.TrimRight(baseURL, "/")}, nil
+}
+
+func (s *localBlobStore) Put(key string, r io.Reader, contentType string) (string, error) {
+	fullPath, err := s.resolve(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+		return "", err
+	}
+
+	//先写临时文件再重命名，避免读到写了一半的文件
+	tmp, err := os.CreateTemp(filepath.Dir(fullPath), ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), fullPath); err != nil {
+		return "", err
+	}
+
+	return s.baseURL + "/" + key, nil
+}
+
+func (s *localBlobStore) Delete(key string) error {
+	fullPath, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(fullPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// resolve 拒绝带..的key，防止写到root目录之外
+func (s *localBlobStore) resolve(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || clean != "/"+key {
+		return "", errors.New("invalid blob key")
+	}
+	return filepath.Join(s.root, filepath.FromSlash(clean)), nil
+}
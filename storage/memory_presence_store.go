@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"steam-backend/models"
+	"sync"
+	"time"
+)
+
+type memoryPresenceStore struct {
+	mu        sync.RWMutex
+	presences map[uint64]models.Presence
+	ttl       time.Duration
+}
+
+func NewMemoryPresenceStore(ttl time.Duration) PresenceStore {
+	return &memoryPresenceStore{
+		presences: make(map[uint64]models.Presence),
+		ttl:       ttl,
+	}
+}
+
+func (s *memoryPresenceStore) Set(userID uint64, presence models.Presence) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.presences[userID] = presence
+	return nil
+}
+
+// GetMany 只返回仍在有效期内的记录，过期记录留给Sweep清理
+func (s *memoryPresenceStore) GetMany(userIDs []uint64) (map[uint64]models.Presence, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	res := make(map[uint64]models.Presence, len(userIDs))
+	for _, id := range userIDs {
+		presence, ok := s.presences[id]
+		if ok && !s.expired(presence, now) {
+			res[id] = presence
+		}
+	}
+	return res, nil
+}
+
+func (s *memoryPresenceStore) Delete(userID uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.presences, userID)
+	return nil
+}
+
+func (s *memoryPresenceStore) Sweep() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, presence := range s.presences {
+		if s.expired(presence, now) {
+			delete(s.presences, id)
+		}
+	}
+	return nil
+}
+
+func (s *memoryPresenceStore) expired(presence models.Presence, now time.Time) bool {
+	return now.Sub(presence.UpdatedAt) > s.ttl
+}
@@ -0,0 +1,13 @@
+package storage
+
+import "steam-backend/models"
+
+// PresenceStore 保存用户在线状态，超过ttl没有心跳的记录视为离线
+// 目前只有进程内实现，多实例部署时可以换成共享缓存
+type PresenceStore interface {
+	Set(userID uint64, presence models.Presence) error
+	GetMany(userIDs []uint64) (map[uint64]models.Presence, error)
+	Delete(userID uint64) error
+	// Sweep 清理过期记录，自带过期机制的实现直接返回nil即可
+	Sweep() error
+}
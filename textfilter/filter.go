@@ -0,0 +1,147 @@
+package textfilter
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// 文本命中过滤规则后的处理方式
+const (
+	ModeReject = "reject" //拒绝提交
+	ModeMask   = "mask"   //敏感词和链接替换为*后保存
+	ModeFlag   = "flag"   //原样保存并提交给版主审核
+)
+
+func IsValidMode(mode string) bool {
+	switch mode {
+	case ModeReject, ModeMask, ModeFlag:
+		return true
+	}
+	return false
+}
+
+// Result Words为命中的词表原词，Links为链接数量，Repetitive表示刷屏
+type Result struct {
+	Words      []string
+	Links      int
+	Repetitive bool
+	Spam       bool //链接数超过上限或刷屏
+}
+
+func (r Result) Clean() bool {
+	return len(r.Words) == 0 && !r.Spam
+}
+
+type rule struct {
+	word     string //词表中的原词
+	pattern  string //归一化后的内容
+	contains bool
+}
+
+// Filter 创建后只读，可以被多个goroutine同时使用
+type Filter struct {
+	rules    []rule
+	maxLinks int
+}
+
+// New maxLinks为一段文本中允许出现的链接数，超过即视为垃圾信息
+func New(words []string, maxLinks int) *Filter {
+	f := &Filter{maxLinks: maxLinks}
+	for _, word := range words {
+		contains := strings.HasPrefix(word, "*") || strings.HasSuffix(word, "*")
+		pattern := normalizeWord(strings.Trim(word, "*"))
+		if pattern == "" {
+			continue
+		}
+		f.rules = append(f.rules, rule{word: strings.Trim(word, "*"), pattern: pattern, contains: contains})
+	}
+	return f
+}
+
+func (f *Filter) Check(text string) Result {
+	runes := []rune(text)
+	tokens := tokenize(runes)
+
+	var res Result
+	seen := make(map[string]bool)
+	for _, t := range tokens {
+		if r := f.match(t); r != nil && !seen[r.word] {
+			seen[r.word] = true
+			res.Words = append(res.Words, r.word)
+		}
+	}
+	res.Links = len(findLinks(text))
+	res.Repetitive = isRepetitive(runes, tokens)
+	res.Spam = res.Links > f.maxLinks || res.Repetitive
+	return res
+}
+
+// Mask 整词命中时替换分词中命中的部分(不含词尾被忽略的符号)，包含命中时只替换命中的部分，链接整体替换；
+// 只替换字母数字，保留空白和标点
+func (f *Filter) Mask(text string) string {
+	runes := []rune(text)
+	masked := make([]bool, len(runes))
+	for _, t := range tokenize(runes) {
+		f.maskToken(t, masked)
+	}
+	for _, loc := range findLinks(text) {
+		//正则返回的是字节下标，需要换算成rune下标
+		start := utf8.RuneCountInString(text[:loc[0]])
+		end := start + utf8.RuneCountInString(text[loc[0]:loc[1]])
+		for i := start; i < end; i++ {
+			masked[i] = true
+		}
+	}
+
+	for i, r := range runes {
+		if masked[i] && normalizeRune(r) != 0 {
+			runes[i] = '*'
+		}
+	}
+	return string(runes)
+}
+
+func (f *Filter) maskToken(t token, masked []bool) {
+	tokenRunes := []rune(t.text)
+	for i := range f.rules {
+		r := &f.rules[i]
+		if n := t.matches(r.pattern); n > 0 {
+			for j := t.start; j <= t.pos[n-1]; j++ {
+				masked[j] = true
+			}
+			return
+		}
+		if !r.contains {
+			continue
+		}
+		patternRunes := []rune(r.pattern)
+		for start := 0; start+len(patternRunes) <= len(tokenRunes); start++ {
+			if string(tokenRunes[start:start+len(patternRunes)]) != r.pattern {
+				continue
+			}
+			for j := t.pos[start]; j <= t.pos[start+len(patternRunes)-1]; j++ {
+				masked[j] = true
+			}
+		}
+	}
+}
+
+func (f *Filter) match(t token) *rule {
+	for i := range f.rules {
+		r := &f.rules[i]
+		if t.matches(r.pattern) > 0 || (r.contains && strings.Contains(t.text, r.pattern)) {
+			return r
+		}
+	}
+	return nil
+}
+
+// matches 整词匹配，允许忽略词尾由符号还原出的字母，返回命中部分的长度，未命中返回0
+func (t token) matches(pattern string) int {
+	for _, v := range t.variants() {
+		if v == pattern {
+			return len([]rune(v))
+		}
+	}
+	return 0
+}
@@ -0,0 +1,90 @@
+package textfilter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckWords(t *testing.T) {
+	f := New([]string{"ass", "bad", "*垃圾*", "*scam*"}, 1)
+
+	cases := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"plain", "you are bad", []string{"bad"}},
+		{"upper case", "BAD game", []string{"bad"}},
+		{"leet digits", "b4d game", []string{"bad"}},
+		{"leet symbols", "you a$$", []string{"ass"}},
+		{"leet symbols with trailing punctuation", "you a$$!", []string{"ass"}},
+		{"trailing punctuation", "so bad!", []string{"bad"}},
+		{"trailing non-leet punctuation", "so bad?!.", []string{"bad"}},
+		{"punctuation inside token", "b.a.d", []string{"bad"}},
+		{"split letters", "b a d idea", []string{"bad"}},
+		{"contains", "total scammer here", []string{"scam"}},
+		{"contains chinese", "这游戏真是垃圾啊", []string{"垃圾"}},
+		{"whole word only", "badge and assets", nil},
+		{"clean", "great game", nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := f.Check(c.text).Words; !reflect.DeepEqual(got, c.want) {
+				t.Errorf("Check(%q).Words = %v, want %v", c.text, got, c.want)
+			}
+		})
+	}
+}
+
+func TestCheckSpam(t *testing.T) {
+	f := New(nil, 1)
+
+	cases := []struct {
+		name       string
+		text       string
+		links      int
+		repetitive bool
+		spam       bool
+	}{
+		{"one link", "see https://example.com/a", 1, false, false},
+		{"too many links", "www.a.com and b.net", 2, false, true},
+		{"repeated runes", "niceeeeeeeeeeee", 0, true, true},
+		{"repeated tokens", "buy buy buy buy buy now", 0, true, true},
+		{"repeated tokens below half", "buy buy buy buy buy and some other real words here", 0, false, false},
+		{"clean", "a normal review", 0, false, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res := f.Check(c.text)
+			if res.Links != c.links || res.Repetitive != c.repetitive || res.Spam != c.spam {
+				t.Errorf("Check(%q) = links %d repetitive %v spam %v, want %d %v %v",
+					c.text, res.Links, res.Repetitive, res.Spam, c.links, c.repetitive, c.spam)
+			}
+		})
+	}
+}
+
+func TestMask(t *testing.T) {
+	f := New([]string{"ass", "bad", "*垃圾*"}, 1)
+
+	cases := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"whole word", "so bad!", "so ***!"},
+		{"leet symbols", "you a$$!", "you ***!"},
+		{"split letters", "b a d idea", "* * * idea"},
+		{"contains multibyte", "这游戏真是垃圾啊", "这游戏真是**啊"},
+		{"multibyte before word", "éé bad", "éé ***"},
+		{"link after multibyte", "看这里 www.a.com 好", "看这里 ***.*.*** 好"},
+		{"clean", "great game", "great game"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := f.Mask(c.text); got != c.want {
+				t.Errorf("Mask(%q) = %q, want %q", c.text, got, c.want)
+			}
+		})
+	}
+}
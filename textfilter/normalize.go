@@ -0,0 +1,126 @@
+package textfilter
+
+import (
+	"strings"
+	"unicode"
+)
+
+// leetMap 常见的用数字和符号替代字母的写法
+var leetMap = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'8': 'b',
+	'@': 'a',
+	'$': 's',
+	'!': 'i',
+	'|': 'l',
+	'+': 't',
+}
+
+// token 原文中的一个分词，start和end为原文中的rune下标，text为归一化后的内容，
+// pos[i]为text中第i个rune在原文中的下标，symbols为text末尾由符号还原出的字母数
+type token struct {
+	start, end int
+	text       string
+	pos        []int
+	symbols    int
+}
+
+// normalizeRune 转为小写并还原leetspeak，返回0表示该字符不参与匹配
+func normalizeRune(r rune) rune {
+	if mapped, ok := leetMap[r]; ok {
+		return mapped
+	}
+	if unicode.IsLetter(r) || unicode.IsDigit(r) {
+		return unicode.ToLower(r)
+	}
+	return 0
+}
+
+// normalizeWord 词表中的词与分词使用相同的归一化规则
+func normalizeWord(word string) string {
+	text, _, _ := normalizeRunes([]rune(word), 0)
+	return text
+}
+
+// normalizeRunes offset为runes在原文中的起始下标，用于计算pos；
+// 词尾不在leetMap中的标点直接去掉，在leetMap中的符号仍然还原，但记录个数，
+// 匹配时再尝试去掉它们，这样"bad!"和"a$$!"都能命中
+func normalizeRunes(runes []rune, offset int) (string, []int, int) {
+	end := len(runes)
+	for end > 0 && normalizeRune(runes[end-1]) == 0 {
+		end--
+	}
+	var b strings.Builder
+	var pos []int
+	symbols := 0
+	for i, r := range runes[:end] {
+		if n := normalizeRune(r); n != 0 {
+			b.WriteRune(n)
+			pos = append(pos, offset+i)
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				symbols = 0
+			} else {
+				symbols++
+			}
+		}
+	}
+	return b.String(), pos, symbols
+}
+
+// variants 分词用于整词匹配的候选内容，依次去掉词尾由符号还原出的字母
+func (t token) variants() []string {
+	runes := []rune(t.text)
+	res := make([]string, 0, t.symbols+1)
+	for i := 0; i <= t.symbols && i < len(runes); i++ {
+		res = append(res, string(runes[:len(runes)-i]))
+	}
+	return res
+}
+
+// tokenize 按空白切分并归一化，分词内的标点直接去掉("b.a.d"视为"bad")；
+// 连续的单字符分词会合并成一个("b a d"视为"bad")
+func tokenize(runes []rune) []token {
+	var raw []token
+	start := -1
+	for i := 0; i <= len(runes); i++ {
+		if i < len(runes) && !unicode.IsSpace(runes[i]) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			if text, pos, symbols := normalizeRunes(runes[start:i], start); text != "" {
+				raw = append(raw, token{start: start, end: i, text: text, pos: pos, symbols: symbols})
+			}
+			start = -1
+		}
+	}
+
+	var res []token
+	for i := 0; i < len(raw); i++ {
+		if len([]rune(raw[i].text)) != 1 {
+			res = append(res, raw[i])
+			continue
+		}
+		merged := raw[i]
+		for i+1 < len(raw) && len([]rune(raw[i+1].text)) == 1 {
+			i++
+			merged.end = raw[i].end
+			merged.text += raw[i].text
+			merged.pos = append(merged.pos, raw[i].pos...)
+			if raw[i].symbols == 1 {
+				merged.symbols++
+			} else {
+				merged.symbols = 0
+			}
+		}
+		res = append(res, merged)
+	}
+	return res
+}
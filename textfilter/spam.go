@@ -0,0 +1,44 @@
+package textfilter
+
+import (
+	"regexp"
+	"unicode"
+)
+
+// linkPattern 匹配带协议或www前缀的链接，以及常见顶级域名结尾的裸域名
+var linkPattern = regexp.MustCompile(`(?i)(https?://|www\.)\S+|\b[a-z0-9-]+(\.[a-z0-9-]+)*\.(com|net|org|io|gg|cn|ru|xyz|top|link|me|cc|co|info|biz)\b(/\S*)?`)
+
+const (
+	//同一字符连续出现的次数上限
+	maxRepeatedRunes = 10
+	//同一分词至少出现这么多次且超过总分词数一半时视为刷屏
+	minRepeatedTokens = 5
+)
+
+func findLinks(text string) [][]int {
+	return linkPattern.FindAllStringIndex(text, -1)
+}
+
+// isRepetitive 判断是否为刷屏内容，如"aaaaaaaaaaaa"或反复粘贴同一个词
+func isRepetitive(runes []rune, tokens []token) bool {
+	run := 0
+	for i, r := range runes {
+		if i > 0 && r == runes[i-1] && !unicode.IsSpace(r) {
+			run++
+			if run >= maxRepeatedRunes {
+				return true
+			}
+		} else {
+			run = 1
+		}
+	}
+
+	counts := make(map[string]int, len(tokens))
+	for _, t := range tokens {
+		counts[t.text]++
+		if counts[t.text] >= minRepeatedTokens && counts[t.text]*2 > len(tokens) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package textfilter
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// LoadWordLists 每个文件一行一个词，空行和#开头的行忽略。
+// 词首或词尾的*表示只要分词中包含该词即命中，否则要求整个分词与该词相同，避免误伤正常单词。
+// 分词按空白切分，中文句子通常整句是一个分词，因此中文词一般需要加*
+func LoadWordLists(paths ...string) ([]string, error) {
+	var res []string
+	for _, path := range paths {
+		words, err := loadWordList(path)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, words...)
+	}
+	return res, nil
+}
+
+func loadWordList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var res []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		res = append(res, line)
+	}
+	return res, scanner.Err()
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter 固定窗口计数限流，每个key在一个窗口内最多通过limit次
+type RateLimiter struct {
+	mu      sync.Mutex
+	window  time.Duration
+	buckets map[string]*rateBucket
+}
+
+type rateBucket struct {
+	start time.Time
+	count int
+}
+
+func NewRateLimiter(window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		window:  window,
+		buckets: make(map[string]*rateBucket),
+	}
+}
+
+// Allow 未超限时计数加一并返回true，超限时返回距离窗口重置的时间
+func (l *RateLimiter) Allow(key string, limit int) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	bucket, ok := l.buckets[key]
+	if !ok || now.Sub(bucket.start) >= l.window {
+		if len(l.buckets) > 10000 {
+			l.sweep(now)
+		}
+		bucket = &rateBucket{start: now}
+		l.buckets[key] = bucket
+	}
+
+	if bucket.count >= limit {
+		return false, bucket.start.Add(l.window).Sub(now)
+	}
+	bucket.count++
+	return true, 0
+}
+
+func (l *RateLimiter) sweep(now time.Time) {
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.start) >= l.window {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -14,23 +14,32 @@ var (
 	ErrTokenNotValidYet = errors.New("token not active yet")
 )
 
+// Claims Scope和ClientID只出现在签发给第三方应用的token中，为空表示用户本人登录拿到的token
 type Claims struct {
-	UserID uint64 `json:"userId"`
+	UserID   uint64 `json:"userId"`
+	Role     string `json:"role,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+	ClientID string `json:"clientId,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID uint64, secret string) (string, error) {
-	expireTime := time.Now().Add(2 * time.Hour)
+func GenerateToken(userID uint64, role string, secret string) (string, error) {
+	return signToken(Claims{UserID: userID, Role: role}, 2*time.Hour, secret)
+}
+
+// GenerateOAuthToken 为第三方应用签发的token不带角色，权限只由scope决定
+func GenerateOAuthToken(userID uint64, clientID, scope string, ttl time.Duration, secret string) (string, error) {
+	return signToken(Claims{UserID: userID, Scope: scope, ClientID: clientID}, ttl, secret)
+}
 
-	claims := Claims{
-		UserID: userID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expireTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    "g",
-			Subject:   fmt.Sprintf("%d", userID),
-		},
+func signToken(claims Claims, ttl time.Duration, secret string) (string, error) {
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		Issuer:    "g",
+		Subject:   fmt.Sprintf("%d", claims.UserID),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)